@@ -0,0 +1,185 @@
+// Package authtoken menerbitkan dan memverifikasi access token JWT (HS256) untuk klien
+// yang tidak memakai cookie session (aplikasi mobile, script). Refresh token tidak
+// berbentuk JWT: nilainya acak dan hanya hash-nya yang disimpan di database.
+package authtoken
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token expired")
+)
+
+const (
+	issuer         = "filoti-backend"
+	typeAccess     = "access"
+	clockSkewLimit = 30 * time.Second
+)
+
+// Issuer menyimpan secret dan masa berlaku token
+type Issuer struct {
+	secret     []byte
+	AccessTTL  time.Duration
+	RefreshTTL time.Duration
+	now        func() time.Time
+}
+
+// Claims adalah isi access token
+type Claims struct {
+	Subject   string `json:"sub"`
+	Issuer    string `json:"iss"`
+	Type      string `json:"typ"`
+	IssuedAt  int64  `json:"iat"`
+	ExpiresAt int64  `json:"exp"`
+	ID        string `json:"jti"`
+}
+
+// UserID mengembalikan subject sebagai ID user
+func (c Claims) UserID() (uint, error) {
+	id, err := strconv.ParseUint(c.Subject, 10, 32)
+	if err != nil {
+		return 0, ErrInvalidToken
+	}
+	return uint(id), nil
+}
+
+// New membuat Issuer. Secret minimal 32 byte agar HS256 tidak mudah ditebak.
+func New(secret []byte, accessTTL, refreshTTL time.Duration) (*Issuer, error) {
+	if len(secret) < 32 {
+		return nil, errors.New("authtoken: secret must be at least 32 bytes")
+	}
+	return &Issuer{secret: secret, AccessTTL: accessTTL, RefreshTTL: refreshTTL, now: time.Now}, nil
+}
+
+// FromEnv membaca JWT_SECRET, ACCESS_TOKEN_TTL (default 15m) dan REFRESH_TOKEN_TTL (default 720h).
+// Mengembalikan nil tanpa error jika JWT_SECRET kosong (autentikasi token dinonaktifkan).
+func FromEnv() (*Issuer, error) {
+	secret := os.Getenv("JWT_SECRET")
+	if secret == "" {
+		return nil, nil
+	}
+	accessTTL, err := durationEnv("ACCESS_TOKEN_TTL", 15*time.Minute)
+	if err != nil {
+		return nil, err
+	}
+	refreshTTL, err := durationEnv("REFRESH_TOKEN_TTL", 30*24*time.Hour)
+	if err != nil {
+		return nil, err
+	}
+	return New([]byte(secret), accessTTL, refreshTTL)
+}
+
+func durationEnv(name string, def time.Duration) (time.Duration, error) {
+	v := os.Getenv(name)
+	if v == "" {
+		return def, nil
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		return 0, fmt.Errorf("authtoken: invalid %s %q", name, v)
+	}
+	return d, nil
+}
+
+var jwtHeader = base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"HS256","typ":"JWT"}`))
+
+// IssueAccess membuat access token untuk user; mengembalikan token dan waktu kedaluwarsanya
+func (i *Issuer) IssueAccess(userID uint) (string, time.Time, error) {
+	now := i.now()
+	expires := now.Add(i.AccessTTL)
+	jti, err := RandomToken(16)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	payload, err := json.Marshal(Claims{
+		Subject:   strconv.FormatUint(uint64(userID), 10),
+		Issuer:    issuer,
+		Type:      typeAccess,
+		IssuedAt:  now.Unix(),
+		ExpiresAt: expires.Unix(),
+		ID:        jti,
+	})
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	signingInput := jwtHeader + "." + base64.RawURLEncoding.EncodeToString(payload)
+	return signingInput + "." + i.sign(signingInput), expires, nil
+}
+
+// VerifyAccess memeriksa tanda tangan, header, issuer, jenis dan masa berlaku access token
+func (i *Issuer) VerifyAccess(token string) (Claims, error) {
+	var claims Claims
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return claims, ErrInvalidToken
+	}
+	signingInput := parts[0] + "." + parts[1]
+	if !hmac.Equal([]byte(parts[2]), []byte(i.sign(signingInput))) {
+		return claims, ErrInvalidToken
+	}
+
+	// Header dicek setelah tanda tangan valid; hanya HS256 yang diterima (menolak alg "none" dkk.)
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return claims, ErrInvalidToken
+	}
+	var header struct {
+		Alg string `json:"alg"`
+	}
+	if err := json.Unmarshal(headerJSON, &header); err != nil || header.Alg != "HS256" {
+		return claims, ErrInvalidToken
+	}
+
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return claims, ErrInvalidToken
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, ErrInvalidToken
+	}
+	if claims.Issuer != issuer || claims.Type != typeAccess {
+		return claims, ErrInvalidToken
+	}
+	now := i.now()
+	if claims.IssuedAt > now.Add(clockSkewLimit).Unix() {
+		return claims, ErrInvalidToken
+	}
+	if now.Unix() >= claims.ExpiresAt {
+		return claims, ErrExpiredToken
+	}
+	return claims, nil
+}
+
+func (i *Issuer) sign(signingInput string) string {
+	mac := hmac.New(sha256.New, i.secret)
+	mac.Write([]byte(signingInput))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// RandomToken membuat string acak URL-safe dari n byte acak
+func RandomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// HashToken mengembalikan SHA-256 hex dari token; yang disimpan di database hanya hash ini
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,145 @@
+package authtoken
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSecret = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestIssuer(t *testing.T, now time.Time) *Issuer {
+	t.Helper()
+	i, err := New(testSecret, 15*time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.now = func() time.Time { return now }
+	return i
+}
+
+// forge menyusun token dengan header dan claims bebas, ditandatangani secret uji jika sign true
+func forge(t *testing.T, header string, claims Claims, sign bool) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	input := base64.RawURLEncoding.EncodeToString([]byte(header)) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	if !sign {
+		return input + "."
+	}
+	mac := hmac.New(sha256.New, testSecret)
+	mac.Write([]byte(input))
+	return input + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+func TestNewRejectsShortSecret(t *testing.T) {
+	if _, err := New([]byte("short"), time.Minute, time.Hour); err == nil {
+		t.Fatal("New accepted a secret shorter than 32 bytes")
+	}
+}
+
+func TestIssueAndVerifyAccess(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	i := newTestIssuer(t, now)
+
+	token, expires, err := i.IssueAccess(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !expires.Equal(now.Add(15 * time.Minute)) {
+		t.Errorf("expires = %v, want %v", expires, now.Add(15*time.Minute))
+	}
+	claims, err := i.VerifyAccess(token)
+	if err != nil {
+		t.Fatalf("VerifyAccess: %v", err)
+	}
+	if id, err := claims.UserID(); err != nil || id != 42 {
+		t.Errorf("UserID = %d, %v; want 42", id, err)
+	}
+	if claims.ID == "" {
+		t.Error("jti is empty")
+	}
+}
+
+func TestVerifyAccessRejects(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	i := newTestIssuer(t, now)
+	valid, _, err := i.IssueAccess(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := strings.Split(valid, ".")
+
+	good := Claims{Subject: "42", Issuer: issuer, Type: typeAccess, IssuedAt: now.Unix(), ExpiresAt: now.Add(time.Minute).Unix()}
+	hs256 := `{"alg":"HS256","typ":"JWT"}`
+	with := func(edit func(*Claims)) Claims {
+		c := good
+		edit(&c)
+		return c
+	}
+	tamperedPayload, _ := json.Marshal(with(func(c *Claims) { c.Subject = "1" }))
+
+	other, err := New([]byte("fedcba9876543210fedcba9876543210"), 15*time.Minute, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	other.now = i.now
+	foreign, _, err := other.IssueAccess(42)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name  string
+		token string
+		want  error
+	}{
+		{"empty", "", ErrInvalidToken},
+		{"two segments", parts[0] + "." + parts[1], ErrInvalidToken},
+		{"tampered payload", parts[0] + "." + base64.RawURLEncoding.EncodeToString(tamperedPayload) + "." + parts[2], ErrInvalidToken},
+		{"tampered signature", parts[0] + "." + parts[1] + "." + strings.Repeat("A", len(parts[2])), ErrInvalidToken},
+		{"signed with another secret", foreign, ErrInvalidToken},
+		{"alg none unsigned", forge(t, `{"alg":"none","typ":"JWT"}`, good, false), ErrInvalidToken},
+		{"alg none signed", forge(t, `{"alg":"none","typ":"JWT"}`, good, true), ErrInvalidToken},
+		{"alg HS512", forge(t, `{"alg":"HS512","typ":"JWT"}`, good, true), ErrInvalidToken},
+		{"wrong issuer", forge(t, hs256, with(func(c *Claims) { c.Issuer = "someone-else" }), true), ErrInvalidToken},
+		{"wrong type", forge(t, hs256, with(func(c *Claims) { c.Type = "refresh" }), true), ErrInvalidToken},
+		{"issued in the future", forge(t, hs256, with(func(c *Claims) { c.IssuedAt = now.Add(time.Hour).Unix() }), true), ErrInvalidToken},
+		{"expired", forge(t, hs256, with(func(c *Claims) { c.ExpiresAt = now.Add(-time.Second).Unix() }), true), ErrExpiredToken},
+		{"expires now", forge(t, hs256, with(func(c *Claims) { c.ExpiresAt = now.Unix() }), true), ErrExpiredToken},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := i.VerifyAccess(tt.token); err != tt.want {
+				t.Errorf("VerifyAccess error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerifyAccessAfterTTL(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	i := newTestIssuer(t, now)
+	token, _, err := i.IssueAccess(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	i.now = func() time.Time { return now.Add(15 * time.Minute) }
+	if _, err := i.VerifyAccess(token); err != ErrExpiredToken {
+		t.Errorf("VerifyAccess after TTL = %v, want ErrExpiredToken", err)
+	}
+}
+
+func TestClaimsUserIDInvalid(t *testing.T) {
+	for _, sub := range []string{"", "abc", "-1", "99999999999"} {
+		if _, err := (Claims{Subject: sub}).UserID(); err != ErrInvalidToken {
+			t.Errorf("UserID(%q) error = %v, want ErrInvalidToken", sub, err)
+		}
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
 
 	"filoti-backend/models" // Pastikan path ini benar
 
@@ -79,9 +80,68 @@ func InitDB() {
 		&models.Post{},
 		&models.Status{},
 		&models.Notification{},
+		&models.Upload{},
+		&models.Claim{},
+		&models.StatusHistory{},
+		&models.LostReport{},
+		&models.MatchSuggestion{},
+		&models.Subscription{},
+		&models.AuditLog{},
+		&models.RefreshToken{},
+		&models.UserSession{},
+		&models.UserToken{},
+		&models.RateLimit{},
+		&models.RecoveryCode{},
+		&models.UserIdentity{},
 	)
 	if err != nil {
 		log.Fatalf("AutoMigrate failed: %v", err)
 	}
+	if err := runRawMigrations(DB); err != nil {
+		log.Fatalf("Raw migration failed: %v", err)
+	}
+	bootstrapSuperAdmin(DB)
 	log.Println("Database connected and migrated successfully.")
 }
+
+// bootstrapSuperAdmin menjadikan user BOOTSTRAP_SUPER_ADMIN (username) sebagai super_admin,
+// untuk deployment baru yang belum punya siapa pun yang bisa mengatur role lewat API.
+func bootstrapSuperAdmin(db *gorm.DB) {
+	username := strings.ToLower(strings.TrimSpace(os.Getenv("BOOTSTRAP_SUPER_ADMIN")))
+	if username == "" {
+		return
+	}
+	result := db.Model(&models.User{}).
+		Where("username = ? AND role <> ?", username, models.RoleSuperAdmin).
+		Update("role", models.RoleSuperAdmin)
+	if result.Error != nil {
+		log.Printf("Failed to bootstrap super admin %s: %v", username, result.Error)
+	} else if result.RowsAffected > 0 {
+		log.Printf("User %s promoted to super_admin (BOOTSTRAP_SUPER_ADMIN)", username)
+	}
+}
+
+// ListenDSN mengembalikan DSN untuk koneksi LISTEN/NOTIFY. Koneksi ini harus langsung
+// ke PostgreSQL (bukan lewat pooler mode transaction), jadi DB_LISTEN_URL diutamakan;
+// jika kosong, dipakai DB_HOST/DB_PORT (koneksi direct) lalu fallback ke host pooler.
+func ListenDSN() string {
+	if dsn := os.Getenv("DB_LISTEN_URL"); dsn != "" {
+		return dsn
+	}
+	host := os.Getenv("DB_HOST")
+	if host == "" {
+		host = os.Getenv("DB_HOST_POOLER")
+	}
+	port := os.Getenv("DB_PORT")
+	if port == "" {
+		port = os.Getenv("DB_PORT_POOLER")
+	}
+	sslmode := os.Getenv("DB_SSLMODE_POOLER")
+	if sslmode == "" {
+		sslmode = "disable"
+	}
+	return fmt.Sprintf(
+		"host=%s user=%s password=%s dbname=%s port=%s sslmode=%s",
+		host, os.Getenv("DB_USER"), os.Getenv("DB_PASSWORD"), os.Getenv("DB_NAME"), port, sslmode,
+	)
+}
@@ -0,0 +1,19 @@
+package config
+
+import (
+	"log"
+
+	"filoti-backend/mailer"
+)
+
+var Mailer mailer.Mailer
+
+// InitMailer menyiapkan pengirim email dari environment (MAIL_DRIVER dkk.)
+func InitMailer() {
+	m, err := mailer.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize mailer: %v", err)
+	}
+	Mailer = m
+	log.Printf("Mailer initialized (%T)", m)
+}
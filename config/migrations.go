@@ -0,0 +1,156 @@
+package config
+
+import (
+	"fmt"
+	"log"
+
+	"gorm.io/gorm"
+)
+
+// Migrasi SQL mentah untuk hal-hal yang tidak bisa diekspresikan lewat tag GORM
+// (generated column, index GIN, backfill data). Setiap migrasi hanya dijalankan sekali;
+// nama yang sudah diterapkan dicatat di tabel schema_migrations.
+var rawMigrations = []struct {
+	name       string
+	statements []string
+}{
+	{
+		name: "posts_search_vector",
+		statements: []string{`ALTER TABLE posts ADD COLUMN IF NOT EXISTS search_vector tsvector
+			GENERATED ALWAYS AS (
+				setweight(to_tsvector('indonesian'::regconfig, coalesce(title, '')), 'A') ||
+				setweight(to_tsvector('english'::regconfig, coalesce(title, '')), 'A') ||
+				setweight(to_tsvector('indonesian'::regconfig, coalesce(keterangan, '')), 'B') ||
+				setweight(to_tsvector('english'::regconfig, coalesce(keterangan, '')), 'B') ||
+				setweight(to_tsvector('simple'::regconfig, coalesce(ruangan, '')), 'C')
+			) STORED`},
+	},
+	{
+		name:       "posts_search_vector_gin",
+		statements: []string{`CREATE INDEX IF NOT EXISTS idx_posts_search_vector ON posts USING GIN (search_vector)`},
+	},
+	{
+		// Post lama belum punya author; isi dari user yang terakhir mengubah statusnya
+		name: "posts_backfill_author",
+		statements: []string{`UPDATE posts SET author_id = s.updated_by
+			FROM statuses s
+			WHERE s.post_id = posts.id
+				AND posts.author_id IS NULL
+				AND EXISTS (SELECT 1 FROM users u WHERE u.id = s.updated_by)`},
+	},
+	{
+		// Admin lama (is_admin) menjadi desk_officer, role admin dengan izin paling sedikit; super_admin
+		// dipilih eksplisit lewat BOOTSTRAP_SUPER_ADMIN. Harus berjalan sebelum notifications_fan_out_legacy;
+		// database baru tidak pernah punya kolom is_admin, jadi dicek dulu.
+		name: "users_role_from_is_admin",
+		statements: []string{`DO $$
+			BEGIN
+				IF EXISTS (SELECT 1 FROM information_schema.columns
+					WHERE table_schema = current_schema() AND table_name = 'users' AND column_name = 'is_admin') THEN
+					UPDATE users SET role = 'desk_officer' WHERE is_admin;
+				END IF;
+			END $$`},
+	},
+	{
+		// Notifikasi lama bersifat global (tanpa user_id); salin untuk setiap admin lalu hapus yang global
+		name: "notifications_fan_out_legacy",
+		statements: []string{
+			`INSERT INTO notifications (user_id, post_id, message, is_read, created_at)
+				SELECT u.id, n.post_id, n.message, n.is_read, n.created_at
+				FROM notifications n CROSS JOIN users u
+				WHERE n.user_id IS NULL AND u.role IN ('desk_officer', 'moderator', 'super_admin')`,
+			`DELETE FROM notifications WHERE user_id IS NULL`,
+		},
+	},
+	{
+		// Tentukan jenis notifikasi lama sekali saja dari teks pesannya
+		name: "notifications_backfill_type",
+		statements: []string{
+			`UPDATE notifications SET type = 'post_created' WHERE type = 'general' AND message ILIKE '%baru dibuat%'`,
+			`UPDATE notifications SET type = 'post_done' WHERE type = 'general' AND message ILIKE '%diselesaikan%'`,
+		},
+	},
+	{
+		// Notifikasi tidak lagi ikut terhapus bersama post-nya (dibutuhkan untuk event post_deleted)
+		name: "notifications_post_fk_set_null",
+		statements: []string{
+			`ALTER TABLE notifications ALTER COLUMN post_id DROP NOT NULL`,
+			`ALTER TABLE notifications DROP CONSTRAINT IF EXISTS fk_posts_notifications`,
+			`ALTER TABLE notifications ADD CONSTRAINT fk_posts_notifications
+				FOREIGN KEY (post_id) REFERENCES posts(id) ON DELETE SET NULL`,
+		},
+	},
+	{
+		// Status lama hanya 1 (active) / 0 (done); yang sudah selesai dianggap returned
+		name:       "statuses_backfill_state",
+		statements: []string{`UPDATE statuses SET state = 'returned' WHERE status = 0`},
+	},
+	{
+		// Kolom is_admin digantikan role (lihat users_role_from_is_admin)
+		name:       "users_drop_is_admin",
+		statements: []string{`ALTER TABLE users DROP COLUMN IF EXISTS is_admin`},
+	},
+	{
+		// Audit log append-only: UPDATE, DELETE dan TRUNCATE ditolak oleh database
+		name: "audit_logs_append_only",
+		statements: []string{
+			`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+				BEGIN
+					RAISE EXCEPTION 'audit_logs is append-only';
+				END;
+			$$ LANGUAGE plpgsql`,
+			`DROP TRIGGER IF EXISTS audit_logs_no_modify ON audit_logs`,
+			`CREATE TRIGGER audit_logs_no_modify BEFORE UPDATE OR DELETE ON audit_logs
+				FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()`,
+			`DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs`,
+			`CREATE TRIGGER audit_logs_no_truncate BEFORE TRUNCATE ON audit_logs
+				FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only()`,
+		},
+	},
+	{
+		// Klaim dan riwayat status adalah arsip (siapa menutup barang apa); tidak ikut terhapus
+		// saat post di-purge dari tempat sampah. post_id tetap berisi ID post yang sudah dihapus.
+		name: "claims_status_histories_keep_after_purge",
+		statements: []string{
+			`ALTER TABLE claims DROP CONSTRAINT IF EXISTS fk_claims_post`,
+			`ALTER TABLE status_histories DROP CONSTRAINT IF EXISTS fk_status_histories_post`,
+		},
+	},
+}
+
+func runRawMigrations(db *gorm.DB) error {
+	if err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		name text PRIMARY KEY,
+		applied_at timestamptz NOT NULL DEFAULT now()
+	)`).Error; err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	var applied []string
+	if err := db.Raw("SELECT name FROM schema_migrations").Scan(&applied).Error; err != nil {
+		return fmt.Errorf("read schema_migrations: %w", err)
+	}
+	done := make(map[string]bool, len(applied))
+	for _, name := range applied {
+		done[name] = true
+	}
+
+	for _, m := range rawMigrations {
+		if done[m.name] {
+			continue
+		}
+		err := db.Transaction(func(tx *gorm.DB) error {
+			for _, stmt := range m.statements {
+				if err := tx.Exec(stmt).Error; err != nil {
+					return err
+				}
+			}
+			return tx.Exec("INSERT INTO schema_migrations (name) VALUES (?) ON CONFLICT DO NOTHING", m.name).Error
+		})
+		if err != nil {
+			return fmt.Errorf("migration %s: %w", m.name, err)
+		}
+		log.Printf("Applied migration %s", m.name)
+	}
+	return nil
+}
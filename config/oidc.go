@@ -0,0 +1,97 @@
+package config
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"filoti-backend/models"
+	"filoti-backend/oidc"
+)
+
+// OIDCProvider adalah provider SSO beserta aturan aplikasi untuk user yang login lewat provider itu
+type OIDCProvider struct {
+	*oidc.Provider
+	GroupsClaim string                 // Nama claim grup di ID token, kosong jika tidak dipakai
+	RoleMap     map[string]models.Role // Grup -> role; role tertinggi yang cocok dipakai
+	AllowSignup bool                   // Buat user baru untuk sub yang belum tertaut
+	LinkByEmail bool                   // Tautkan ke user lokal dengan email terverifikasi yang sama
+}
+
+// OIDCProviders berisi provider dari OIDC_PROVIDERS; kosong berarti login SSO dinonaktifkan
+var OIDCProviders = map[string]*OIDCProvider{}
+
+// InitOIDC membaca provider SSO dari environment. Contoh untuk provider "campus":
+//
+//	OIDC_PROVIDERS=campus
+//	OIDC_CAMPUS_ISSUER=https://sso.kampus.ac.id/realms/students
+//	OIDC_CAMPUS_CLIENT_ID=filoti
+//	OIDC_CAMPUS_CLIENT_SECRET=...            (kosongkan untuk public client)
+//	OIDC_CAMPUS_REDIRECT_URL=https://api.example.com/auth/oidc/callback
+//	OIDC_CAMPUS_SCOPES=openid profile email groups
+//	OIDC_CAMPUS_GROUPS_CLAIM=groups
+//	OIDC_CAMPUS_ROLE_MAP=lost-found-desk=desk_officer,it-admins=moderator
+//	OIDC_CAMPUS_ALLOW_SIGNUP=true
+//	OIDC_CAMPUS_LINK_BY_EMAIL=false
+//
+// Jika ROLE_MAP diisi, role user selalu disamakan dengan grupnya setiap login SSO
+// (tanpa grup yang cocok menjadi student), kecuali super admin terakhir.
+func InitOIDC() {
+	for _, name := range strings.Split(os.Getenv("OIDC_PROVIDERS"), ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		provider, err := oidcProviderFromEnv(name)
+		if err != nil {
+			log.Fatalf("Failed to initialize OIDC provider %s: %v", name, err)
+		}
+		OIDCProviders[name] = provider
+		log.Printf("OIDC provider %s initialized (%s)", name, provider.Config.Issuer)
+	}
+}
+
+func oidcProviderFromEnv(name string) (*OIDCProvider, error) {
+	prefix := "OIDC_" + strings.ToUpper(strings.ReplaceAll(name, "-", "_")) + "_"
+	env := func(key string) string { return strings.TrimSpace(os.Getenv(prefix + key)) }
+
+	cfg := oidc.Config{
+		Name:         name,
+		DisplayName:  env("DISPLAY_NAME"),
+		Issuer:       env("ISSUER"),
+		ClientID:     env("CLIENT_ID"),
+		ClientSecret: env("CLIENT_SECRET"),
+		RedirectURL:  env("REDIRECT_URL"),
+		Scopes:       strings.Fields(env("SCOPES")),
+	}
+	if cfg.Issuer == "" || cfg.ClientID == "" || cfg.RedirectURL == "" {
+		return nil, fmt.Errorf("%sISSUER, %sCLIENT_ID and %sREDIRECT_URL must be set", prefix, prefix, prefix)
+	}
+	if cfg.DisplayName == "" {
+		cfg.DisplayName = name
+	}
+
+	provider := &OIDCProvider{
+		Provider:    oidc.New(cfg),
+		GroupsClaim: env("GROUPS_CLAIM"),
+		RoleMap:     map[string]models.Role{},
+		AllowSignup: env("ALLOW_SIGNUP") != "false",
+		LinkByEmail: env("LINK_BY_EMAIL") == "true",
+	}
+	for _, pair := range strings.Split(env("ROLE_MAP"), ",") {
+		if strings.TrimSpace(pair) == "" {
+			continue
+		}
+		group, role, ok := strings.Cut(pair, "=")
+		r := models.Role(strings.TrimSpace(role))
+		if !ok || strings.TrimSpace(group) == "" || !r.Valid() {
+			return nil, fmt.Errorf("invalid %sROLE_MAP entry %q", prefix, pair)
+		}
+		provider.RoleMap[strings.TrimSpace(group)] = r
+	}
+	if len(provider.RoleMap) > 0 && provider.GroupsClaim == "" {
+		return nil, fmt.Errorf("%sROLE_MAP requires %sGROUPS_CLAIM", prefix, prefix)
+	}
+	return provider, nil
+}
@@ -0,0 +1,33 @@
+package config
+
+import (
+	"log"
+	"os"
+	"strings"
+
+	"filoti-backend/ratelimit"
+)
+
+var Limiter *ratelimit.Limiter
+
+// InitRateLimiter memilih penyimpanan counter rate limit dari RATE_LIMIT_STORE ("memory" atau
+// "postgres"). Default postgres di Vercel (instance tidak berbagi memori), memory di tempat lain.
+// Harus dipanggil setelah InitDB.
+func InitRateLimiter() {
+	store := strings.ToLower(os.Getenv("RATE_LIMIT_STORE"))
+	if store == "" {
+		store = "memory"
+		if os.Getenv("VERCEL") != "" {
+			store = "postgres"
+		}
+	}
+	switch store {
+	case "memory":
+		Limiter = ratelimit.New(ratelimit.NewMemory())
+	case "postgres":
+		Limiter = ratelimit.New(ratelimit.NewPostgres(DB))
+	default:
+		log.Fatalf("Unknown RATE_LIMIT_STORE %q", store)
+	}
+	log.Printf("Rate limiter initialized (%s)", store)
+}
@@ -0,0 +1,26 @@
+package config
+
+import (
+	"os"
+
+	"filoti-backend/models"
+)
+
+// TwoFactorRequired mengembalikan true jika kebijakan mewajibkan user memakai 2FA.
+// REQUIRE_ADMIN_2FA=true mewajibkannya untuk semua role admin (models.Role.IsAdmin).
+func TwoFactorRequired(user models.User) bool {
+	return os.Getenv("REQUIRE_ADMIN_2FA") == "true" && user.IsAdmin()
+}
+
+// TrustedPlatformHeader mengembalikan header berisi IP klien asli yang ditulis platform hosting.
+// Di Vercel defaultnya X-Vercel-Forwarded-For; di luar Vercel kosong (IP diambil dari koneksi)
+// kecuali TRUSTED_PLATFORM_HEADER diisi. X-Forwarded-For tidak dipercaya karena bisa dipalsukan klien.
+func TrustedPlatformHeader() string {
+	if header := os.Getenv("TRUSTED_PLATFORM_HEADER"); header != "" {
+		return header
+	}
+	if os.Getenv("VERCEL") != "" {
+		return "X-Vercel-Forwarded-For"
+	}
+	return ""
+}
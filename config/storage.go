@@ -0,0 +1,19 @@
+package config
+
+import (
+	"log"
+
+	"filoti-backend/storage"
+)
+
+var Storage storage.Storage
+
+// InitStorage menyiapkan backend penyimpanan file dari environment (STORAGE_DRIVER dkk.)
+func InitStorage() {
+	s, err := storage.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize storage: %v", err)
+	}
+	Storage = s
+	log.Printf("Storage initialized (%T)", s)
+}
@@ -0,0 +1,22 @@
+package config
+
+import (
+	"log"
+
+	"filoti-backend/authtoken"
+)
+
+// Tokens bernilai nil jika JWT_SECRET tidak diset; autentikasi Bearer token dinonaktifkan
+var Tokens *authtoken.Issuer
+
+// InitTokens menyiapkan penerbit access token dari environment (JWT_SECRET dkk.)
+func InitTokens() {
+	issuer, err := authtoken.FromEnv()
+	if err != nil {
+		log.Fatalf("Failed to initialize token issuer: %v", err)
+	}
+	Tokens = issuer
+	if issuer == nil {
+		log.Println("JWT_SECRET not set; bearer token authentication disabled")
+	}
+}
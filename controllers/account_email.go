@@ -0,0 +1,370 @@
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"filoti-backend/authtoken"
+	"filoti-backend/config"
+	"filoti-backend/mailer"
+	"filoti-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Masa berlaku token yang dikirim lewat email
+const (
+	passwordResetTTL     = time.Hour
+	emailVerificationTTL = 24 * time.Hour
+	mailSendTimeout      = 15 * time.Second
+
+	// Waktu respons minimum POST /password/forgot, di atas waktu kirim SMTP yang wajar
+	forgotPasswordMinDuration = 5 * time.Second
+)
+
+var errInvalidUserToken = &tokenError{http.StatusBadRequest, "Invalid or expired token"}
+
+// appURL adalah alamat frontend untuk link di email (APP_URL)
+func appURL() string {
+	if v := os.Getenv("APP_URL"); v != "" {
+		return strings.TrimRight(v, "/")
+	}
+	return "https://filoti-frontend.vercel.app"
+}
+
+// normalizeEmail memvalidasi alamat email dan mengembalikannya dalam huruf kecil
+func normalizeEmail(raw string) (string, bool) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	addr, err := mail.ParseAddress(raw)
+	if err != nil || addr.Address != raw || len(raw) > 255 {
+		return "", false
+	}
+	return raw, true
+}
+
+// createUserToken menerbitkan token sekali pakai dan membatalkan token lain dengan tujuan yang sama
+func createUserToken(tx *gorm.DB, userID uint, purpose models.UserTokenPurpose, email string, ttl time.Duration) (string, error) {
+	if err := tx.Model(&models.UserToken{}).
+		Where("user_id = ? AND purpose = ? AND used_at IS NULL", userID, purpose).
+		Update("used_at", time.Now()).Error; err != nil {
+		return "", err
+	}
+	raw, err := authtoken.RandomToken(32)
+	if err != nil {
+		return "", err
+	}
+	token := models.UserToken{
+		UserID:    userID,
+		Purpose:   purpose,
+		TokenHash: authtoken.HashToken(raw),
+		Email:     email,
+		ExpiresAt: time.Now().Add(ttl),
+	}
+	if err := tx.Create(&token).Error; err != nil {
+		return "", err
+	}
+	return raw, nil
+}
+
+// consumeUserToken mengunci token yang masih berlaku lalu menandainya sudah dipakai
+func consumeUserToken(tx *gorm.DB, raw string, purpose models.UserTokenPurpose) (models.UserToken, error) {
+	var token models.UserToken
+	if raw == "" {
+		return token, errInvalidUserToken
+	}
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ? AND purpose = ?", authtoken.HashToken(raw), purpose).
+		First(&token).Error
+	if err == gorm.ErrRecordNotFound {
+		return token, errInvalidUserToken
+	}
+	if err != nil {
+		return token, err
+	}
+	now := time.Now()
+	if !token.Usable(now) {
+		return token, errInvalidUserToken
+	}
+	token.UsedAt = &now
+	return token, tx.Model(&token).Update("used_at", now).Error
+}
+
+// sendMail mengirim email dengan batas waktu, terikat ke context request
+func sendMail(c *gin.Context, msg mailer.Message) error {
+	if config.Mailer == nil {
+		return fmt.Errorf("mailer is not configured")
+	}
+	ctx, cancel := context.WithTimeout(c.Request.Context(), mailSendTimeout)
+	defer cancel()
+	return config.Mailer.Send(ctx, msg)
+}
+
+// sendVerificationEmail membuat token verifikasi untuk alamat email baru user lalu mengirimkannya
+func sendVerificationEmail(c *gin.Context, user models.User, email string) error {
+	var raw string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		raw, err = createUserToken(tx, user.ID, models.TokenEmailVerification, email, emailVerificationTTL)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	link := appURL() + "/verify-email?token=" + url.QueryEscape(raw)
+	return sendMail(c, mailer.Message{
+		To:      email,
+		Subject: "Verifikasi email FILOTI",
+		Body: fmt.Sprintf("Halo %s,\n\nKlik link berikut untuk memverifikasi email Anda:\n%s\n\n"+
+			"Link berlaku selama %d jam. Abaikan email ini jika Anda tidak merasa mendaftarkannya.\n",
+			user.Username, link, int(emailVerificationTTL.Hours())),
+	})
+}
+
+// Input untuk POST /me/email
+type EmailInput struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// RequestEmailVerification handler: POST /me/email — mengirim link verifikasi ke alamat email baru.
+// Email di akun baru berubah setelah link diklik.
+func RequestEmailVerification(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var input EmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	email, valid := normalizeEmail(input.Email)
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.Email != nil && *user.Email == email {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Email is already verified"})
+		return
+	}
+	var taken int64
+	if err := config.DB.Model(&models.User{}).Where("email = ?", email).Count(&taken).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to check email: " + err.Error()})
+		return
+	}
+	if taken > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "Email already in use"})
+		return
+	}
+
+	if err := sendVerificationEmail(c, user, email); err != nil {
+		log.Printf("RequestEmailVerification: failed to send to user %d - %v", user.ID, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to send verification email"})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{"message": "Verification email sent"})
+}
+
+// Input untuk POST /email/verify
+type VerifyEmailInput struct {
+	Token string `json:"token" binding:"required"`
+}
+
+// VerifyEmail handler: POST /email/verify — menukar token dari email dan menyimpan alamatnya di akun
+func VerifyEmail(c *gin.Context) {
+	var input VerifyEmailInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var user models.User
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := consumeUserToken(tx, strings.TrimSpace(input.Token), models.TokenEmailVerification)
+		if err != nil {
+			return err
+		}
+		var taken int64
+		if err := tx.Model(&models.User{}).Where("email = ? AND id <> ?", token.Email, token.UserID).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken > 0 {
+			return &tokenError{http.StatusConflict, "Email already in use"}
+		}
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			return err
+		}
+		before := user
+		now := time.Now()
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"email":             token.Email,
+			"email_verified_at": now,
+		}).Error; err != nil {
+			return err
+		}
+		user.Email = &token.Email
+		user.EmailVerifiedAt = &now
+		return recordAudit(tx, c, auditEntry{
+			Action: "user.email_verify", EntityType: "user", EntityID: user.ID,
+			Before: before, After: user, ActorID: user.ID,
+		})
+	})
+	if err != nil {
+		if te, ok := err.(*tokenError); ok {
+			c.JSON(te.status, gin.H{"error": te.message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify email: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Email verified", "email": user.Email})
+}
+
+// Input untuk POST /password/forgot
+type ForgotPasswordInput struct {
+	Email string `json:"email" binding:"required"`
+}
+
+// ForgotPassword handler: POST /password/forgot — mengirim link reset ke email terverifikasi.
+// Respons selalu sama agar endpoint ini tidak bisa dipakai untuk menebak email terdaftar.
+func ForgotPassword(c *gin.Context) {
+	if !allowRequest(c, "password_forgot", forgotRateRule) {
+		return
+	}
+	var input ForgotPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	accepted := gin.H{"message": "If the email is registered, a password reset link has been sent"}
+
+	email, valid := normalizeEmail(input.Email)
+	if !valid {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+		return
+	}
+	// Email dikirim di dalam handler (instance Vercel dibekukan setelah handler selesai), lalu
+	// respons ditahan sampai forgotPasswordMinDuration agar email terdaftar dan tidak terdaftar
+	// tidak bisa dibedakan dari waktu respons
+	start := time.Now()
+	var user models.User
+	if err := config.DB.Where("email = ? AND email_verified_at IS NOT NULL", email).First(&user).Error; err != nil {
+		if err != gorm.ErrRecordNotFound {
+			log.Printf("ForgotPassword: failed to look up email - %v", err)
+		}
+	} else {
+		sendPasswordReset(c, user, email)
+	}
+	time.Sleep(time.Until(start.Add(forgotPasswordMinDuration)))
+	c.JSON(http.StatusAccepted, accepted)
+}
+
+// sendPasswordReset membuat token reset dan mengirim email-nya; error hanya di-log
+// karena respons ForgotPassword selalu sama
+func sendPasswordReset(c *gin.Context, user models.User, email string) {
+	if user.IsSuspended() {
+		recordAuditBestEffort(c, auditEntry{Action: "auth.password_forgot_blocked", EntityType: "user", EntityID: user.ID})
+		return
+	}
+
+	var raw string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if raw, err = createUserToken(tx, user.ID, models.TokenPasswordReset, email, passwordResetTTL); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, auditEntry{Action: "auth.password_forgot", EntityType: "user", EntityID: user.ID})
+	})
+	if err != nil {
+		log.Printf("ForgotPassword: failed to create token for user %d - %v", user.ID, err)
+		return
+	}
+
+	link := appURL() + "/reset-password?token=" + url.QueryEscape(raw)
+	if err := sendMail(c, mailer.Message{
+		To:      email,
+		Subject: "Reset password FILOTI",
+		Body: fmt.Sprintf("Halo %s,\n\nKami menerima permintaan reset password untuk akun Anda. "+
+			"Klik link berikut untuk membuat password baru:\n%s\n\n"+
+			"Link berlaku selama %d menit dan hanya bisa dipakai sekali. "+
+			"Abaikan email ini jika Anda tidak memintanya.\n",
+			user.Username, link, int(passwordResetTTL.Minutes())),
+	}); err != nil {
+		log.Printf("ForgotPassword: failed to send email to user %d - %v", user.ID, err)
+	}
+}
+
+// Input untuk POST /password/reset
+type ResetPasswordInput struct {
+	Token    string `json:"token" binding:"required"`
+	Password string `json:"password" binding:"required"` // Minimal minPasswordLength setelah dipangkas, sama seperti ChangePassword
+}
+
+// ResetPassword handler: POST /password/reset — mengganti password dengan token dari email,
+// lalu me-revoke semua session dan refresh token user
+func ResetPassword(c *gin.Context) {
+	var input ResetPasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	password := strings.TrimSpace(input.Password) // Sama seperti Signup/Login
+	if !validPasswordLength(password) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("Password must be at least %d characters", minPasswordLength)})
+		return
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		token, err := consumeUserToken(tx, strings.TrimSpace(input.Token), models.TokenPasswordReset)
+		if err != nil {
+			return err
+		}
+		var user models.User
+		if err := tx.First(&user, token.UserID).Error; err != nil {
+			return err
+		}
+		if user.IsSuspended() {
+			return &tokenError{http.StatusForbidden, "Account suspended"}
+		}
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password":             string(hashed),
+			"must_change_password": false,
+		}).Error; err != nil {
+			return err
+		}
+		if err := revokeUserAccess(tx, user.ID, ""); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, auditEntry{Action: "auth.password_reset", EntityType: "user", EntityID: user.ID, ActorID: user.ID})
+	})
+	if err != nil {
+		if te, ok := err.(*tokenError); ok {
+			c.JSON(te.status, gin.H{"error": te.message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to reset password: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Password has been reset; please log in again"})
+}
@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"os"
+	"strconv"
+	"testing"
+	"time"
+
+	"filoti-backend/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+func TestUserTokenUsable(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	used := now.Add(-time.Minute)
+	tests := []struct {
+		name  string
+		token models.UserToken
+		want  bool
+	}{
+		{"fresh", models.UserToken{ExpiresAt: now.Add(time.Hour)}, true},
+		{"expires in a second", models.UserToken{ExpiresAt: now.Add(time.Second)}, true},
+		{"expires now", models.UserToken{ExpiresAt: now}, false},
+		{"expired", models.UserToken{ExpiresAt: now.Add(-time.Second)}, false},
+		{"used", models.UserToken{ExpiresAt: now.Add(time.Hour), UsedAt: &used}, false},
+	}
+	for _, tt := range tests {
+		if got := tt.token.Usable(now); got != tt.want {
+			t.Errorf("%s: Usable = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+// newTestDB membuka TEST_DATABASE_URL; test dilewati jika tidak diset
+func newTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	dsn := os.Getenv("TEST_DATABASE_URL")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := db.AutoMigrate(&models.User{}, &models.UserToken{}); err != nil {
+		t.Fatal(err)
+	}
+	return db
+}
+
+func TestConsumeUserToken(t *testing.T) {
+	db := newTestDB(t)
+	user := models.User{Username: "token-test-" + strconv.FormatInt(time.Now().UnixNano(), 10), Password: "x"}
+	if err := db.Create(&user).Error; err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Delete(&user) }) // Token ikut terhapus (ON DELETE CASCADE)
+
+	consume := func(raw string, purpose models.UserTokenPurpose) error {
+		return db.Transaction(func(tx *gorm.DB) error {
+			_, err := consumeUserToken(tx, raw, purpose)
+			return err
+		})
+	}
+	create := func(ttl time.Duration) string {
+		t.Helper()
+		raw, err := createUserToken(db, user.ID, models.TokenPasswordReset, "budi@example.com", ttl)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return raw
+	}
+
+	raw := create(time.Hour)
+	if err := consume(raw, models.TokenEmailVerification); err != errInvalidUserToken {
+		t.Errorf("consume with other purpose = %v, want errInvalidUserToken", err)
+	}
+	if err := consume(raw, models.TokenPasswordReset); err != nil {
+		t.Fatalf("first consume: %v", err)
+	}
+	if err := consume(raw, models.TokenPasswordReset); err != errInvalidUserToken {
+		t.Errorf("second consume = %v, want errInvalidUserToken (single use)", err)
+	}
+
+	expired := create(-time.Second)
+	if err := consume(expired, models.TokenPasswordReset); err != errInvalidUserToken {
+		t.Errorf("consume expired = %v, want errInvalidUserToken", err)
+	}
+
+	// Token baru dengan tujuan yang sama membatalkan token sebelumnya
+	older := create(time.Hour)
+	newer := create(time.Hour)
+	if err := consume(older, models.TokenPasswordReset); err != errInvalidUserToken {
+		t.Errorf("consume superseded token = %v, want errInvalidUserToken", err)
+	}
+	if err := consume(newer, models.TokenPasswordReset); err != nil {
+		t.Errorf("consume newest token: %v", err)
+	}
+
+	if err := consume("", models.TokenPasswordReset); err != errInvalidUserToken {
+		t.Errorf("consume empty = %v, want errInvalidUserToken", err)
+	}
+}
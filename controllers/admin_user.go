@@ -0,0 +1,391 @@
+package controllers
+
+import (
+	"crypto/rand"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"filoti-backend/authtoken"
+	"filoti-backend/config"
+	"filoti-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+// GetUsers handler: GET /admin/users — daftar user dengan pencarian dan cursor pagination.
+//
+// Query yang didukung:
+//   - q: potongan username (case-insensitive)
+//   - role: salah satu models.Role
+//   - status: "active" atau "suspended"
+//   - limit, cursor: sama seperti GET /posts
+func GetUsers(c *gin.Context) {
+	limit, err := parseLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := config.DB.Model(&models.User{})
+	if q := strings.TrimSpace(c.Query("q")); q != "" {
+		query = query.Where("username ILIKE ?", "%"+escapeLike(strings.ToLower(q))+"%")
+	}
+	if roleParam := c.Query("role"); roleParam != "" {
+		role := models.Role(roleParam)
+		if !role.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role: " + roleParam})
+			return
+		}
+		query = query.Where("role = ?", role)
+	}
+	switch c.Query("status") {
+	case "":
+	case "active":
+		query = query.Where("suspended_at IS NULL")
+	case "suspended":
+		query = query.Where("suspended_at IS NOT NULL")
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be 'active' or 'suspended'"})
+		return
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count users: " + err.Error()})
+		return
+	}
+
+	if cursorParam := c.Query("cursor"); cursorParam != "" {
+		cursor, err := decodeCursor(cursorParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	var users []models.User
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&users).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve users: " + err.Error()})
+		return
+	}
+
+	hasMore := len(users) > limit
+	if hasMore {
+		users = users[:limit]
+	}
+	var nextCursor interface{}
+	if hasMore {
+		last := users[len(users)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"users":       users,
+		"next_cursor": nextCursor,
+		"has_more":    hasMore,
+		"total":       total,
+		"limit":       limit,
+	})
+}
+
+// GetUser handler: GET /admin/users/:id
+func GetUser(c *gin.Context) {
+	var user models.User
+	if err := config.DB.First(&user, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve user: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"user": user, "permissions": user.Role.Permissions()})
+}
+
+// Input untuk suspend user
+type SuspendUserInput struct {
+	Reason string `json:"reason" binding:"required"`
+}
+
+// SuspendUser handler: POST /admin/users/:id/suspend — akun tidak bisa login sampai di-unsuspend
+func SuspendUser(c *gin.Context) {
+	var input SuspendUserInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	manageUser(c, "user.suspend", "Failed to suspend user", func(tx *gorm.DB, user *models.User) error {
+		if user.IsSuspended() {
+			return &roleError{http.StatusConflict, "User is already suspended"}
+		}
+		if err := ensureNotLastSuperAdmin(tx, *user, "suspend"); err != nil {
+			return err
+		}
+		now := time.Now()
+		reason := strings.TrimSpace(input.Reason)
+		if err := tx.Model(user).Updates(map[string]interface{}{"suspended_at": now, "suspended_reason": reason}).Error; err != nil {
+			return err
+		}
+		if err := revokeUserAccess(tx, user.ID, ""); err != nil {
+			return err
+		}
+		user.SuspendedAt, user.SuspendedReason = &now, reason
+		return nil
+	})
+}
+
+// UnsuspendUser handler: POST /admin/users/:id/unsuspend
+func UnsuspendUser(c *gin.Context) {
+	manageUser(c, "user.unsuspend", "Failed to unsuspend user", func(tx *gorm.DB, user *models.User) error {
+		if !user.IsSuspended() {
+			return &roleError{http.StatusConflict, "User is not suspended"}
+		}
+		if err := tx.Model(user).Updates(map[string]interface{}{"suspended_at": nil, "suspended_reason": ""}).Error; err != nil {
+			return err
+		}
+		user.SuspendedAt, user.SuspendedReason = nil, ""
+		return nil
+	})
+}
+
+// ResetUserPassword handler: POST /admin/users/:id/reset-password — membuat password sementara.
+// Password hanya ditampilkan sekali di respons; user wajib menggantinya saat login berikutnya.
+func ResetUserPassword(c *gin.Context) {
+	temporary, err := temporaryPassword(12)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate password: " + err.Error()})
+		return
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(temporary), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+	user, ok := manageUserTx(c, "user.password_reset", "Failed to reset password", func(tx *gorm.DB, user *models.User) error {
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"password":             string(hashed),
+			"must_change_password": true,
+		}).Error; err != nil {
+			return err
+		}
+		if err := revokeUserAccess(tx, user.ID, ""); err != nil {
+			return err
+		}
+		user.MustChangePassword = true
+		return nil
+	})
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"message":            "Password reset; share the temporary password with the user",
+		"user":               user,
+		"temporary_password": temporary,
+	})
+}
+
+// DeleteUser handler: DELETE /admin/users/:id — menghapus akun permanen.
+// Klaim, laporan kehilangan, notifikasi dan langganan user ikut terhapus; post tetap ada tanpa author.
+func DeleteUser(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid User ID"})
+		return
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := lockManagedUser(tx, uint(targetID), actorID)
+		if err != nil {
+			return err
+		}
+		if err := ensureNotLastSuperAdmin(tx, user, "delete"); err != nil {
+			return err
+		}
+		if err := tx.Delete(&user).Error; err != nil {
+			return err
+		}
+		return recordAudit(tx, c, auditEntry{Action: "user.delete", EntityType: "user", EntityID: user.ID, Before: user})
+	})
+	if err != nil {
+		writeRoleError(c, err, "Failed to delete user")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User deleted"})
+}
+
+// manageUser menjalankan perubahan akun lalu menulis user hasil perubahan sebagai respons
+func manageUser(c *gin.Context, action, failure string, apply func(tx *gorm.DB, user *models.User) error) {
+	user, ok := manageUserTx(c, action, failure, apply)
+	if !ok {
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "User updated successfully", "user": user})
+}
+
+// manageUserTx mengunci user target, menjalankan apply dan mencatat audit before/after dalam satu transaksi.
+// Mengembalikan false jika respons error sudah ditulis.
+func manageUserTx(c *gin.Context, action, failure string, apply func(tx *gorm.DB, user *models.User) error) (models.User, bool) {
+	var user models.User
+	actorID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return user, false
+	}
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid User ID"})
+		return user, false
+	}
+
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err = lockManagedUser(tx, uint(targetID), actorID)
+		if err != nil {
+			return err
+		}
+		before := user
+		if err := apply(tx, &user); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, auditEntry{Action: action, EntityType: "user", EntityID: user.ID, Before: before, After: user})
+	})
+	if err != nil {
+		writeRoleError(c, err, failure)
+		return user, false
+	}
+	return user, true
+}
+
+// minPasswordLength dicek setelah TrimSpace (bukan lewat binding min=8) karena spasi di awal/akhir dibuang
+const minPasswordLength = 8
+
+// validPasswordLength menghitung rune seperti validator min=8
+func validPasswordLength(password string) bool {
+	return utf8.RuneCountInString(password) >= minPasswordLength
+}
+
+// Input untuk mengganti password sendiri
+type ChangePasswordInput struct {
+	CurrentPassword string `json:"current_password" binding:"required"`
+	NewPassword     string `json:"new_password" binding:"required"` // Minimal minPasswordLength setelah dipangkas
+}
+
+// ChangePassword handler: POST /me/password — juga dipakai setelah reset password oleh admin.
+// Semua refresh token user di-revoke, termasuk milik klien yang memanggil; klien Bearer
+// karena itu menerima pasangan token baru (field yang sama dengan POST /auth/token) di respons ini.
+func ChangePassword(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var input ChangePasswordInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.CurrentPassword = strings.TrimSpace(input.CurrentPassword)
+	input.NewPassword = strings.TrimSpace(input.NewPassword) // Sama seperti Signup/Login
+	if !validPasswordLength(input.NewPassword) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("New password must be at least %d characters", minPasswordLength)})
+		return
+	}
+
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.CurrentPassword)); err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Current password is incorrect"})
+		return
+	}
+	if input.NewPassword == input.CurrentPassword {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "New password must be different"})
+		return
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(input.NewPassword), bcrypt.DefaultCost)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to hash password"})
+		return
+	}
+
+	bearer := usesBearerToken(c) && config.Tokens != nil
+	var refresh string
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"password":             string(hashed),
+			"must_change_password": false,
+		}).Error; err != nil {
+			return err
+		}
+		// Perangkat lain harus login ulang; session yang dipakai sekarang tetap aktif
+		if err := revokeUserAccess(tx, user.ID, currentSessionHash(c)); err != nil {
+			return err
+		}
+		if bearer {
+			// Family lama ikut di-revoke di atas, jadi klien ini diberi family baru
+			familyID, err := authtoken.RandomToken(16)
+			if err != nil {
+				return err
+			}
+			if refresh, _, err = createRefreshToken(tx, c, user.ID, familyID); err != nil {
+				return err
+			}
+		}
+		return recordAudit(tx, c, auditEntry{Action: "user.password_change", EntityType: "user", EntityID: user.ID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to change password: " + err.Error()})
+		return
+	}
+	if !bearer {
+		c.JSON(http.StatusOK, gin.H{"message": "Password changed successfully"})
+		return
+	}
+
+	user.MustChangePassword = false
+	body, err := tokenResponse(user, refresh)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Password changed but failed to issue token: " + err.Error()})
+		return
+	}
+	body["message"] = "Password changed successfully"
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, body)
+}
+
+// temporaryPassword membuat password acak tanpa karakter yang mudah tertukar (0/O, 1/l)
+func temporaryPassword(length int) (string, error) {
+	return randomString("abcdefghijkmnpqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789", length)
+}
+
+// randomString memilih length karakter acak (crypto/rand) dari alphabet
+func randomString(alphabet string, length int) (string, error) {
+	b := make([]byte, length)
+	for i := range b {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(alphabet))))
+		if err != nil {
+			return "", err
+		}
+		b[i] = alphabet[n.Int64()]
+	}
+	return string(b), nil
+}
+
+// escapeLike meng-escape karakter wildcard LIKE dari input user
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
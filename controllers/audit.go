@@ -0,0 +1,234 @@
+package controllers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"filoti-backend/config"
+	"filoti-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// auditEntry adalah satu aksi yang dicatat ke audit log
+type auditEntry struct {
+	Action     string
+	EntityType string
+	EntityID   interface{}
+	Before     interface{} // Snapshot sebelum aksi; nil untuk aksi create
+	After      interface{} // Snapshot sesudah aksi; nil untuk aksi delete
+	ActorID    uint        // Diisi jika aktor belum ada di context (login, signup)
+}
+
+// recordAudit menyimpan entri audit di dalam transaksi tx, sehingga ikut batal jika aksinya gagal.
+// Aktor, IP dan user agent diambil dari request.
+func recordAudit(tx *gorm.DB, c *gin.Context, entry auditEntry) error {
+	entryLog := models.AuditLog{
+		Action:     entry.Action,
+		EntityType: entry.EntityType,
+		IP:         c.ClientIP(),
+		UserAgent:  c.Request.UserAgent(),
+	}
+	if entry.EntityID != nil {
+		entryLog.EntityID = fmt.Sprint(entry.EntityID)
+	}
+	actorID, ok := currentUserID(c)
+	if entry.ActorID != 0 {
+		actorID, ok = entry.ActorID, true
+	}
+	if ok {
+		entryLog.ActorID = &actorID
+		entryLog.ActorName = usernameOf(tx, actorID)
+	}
+
+	var err error
+	if entryLog.Before, err = auditSnapshot(entry.Before); err != nil {
+		return err
+	}
+	if entryLog.After, err = auditSnapshot(entry.After); err != nil {
+		return err
+	}
+	return tx.Create(&entryLog).Error
+}
+
+func auditSnapshot(v interface{}) (json.RawMessage, error) {
+	if v == nil {
+		return nil, nil
+	}
+	return json.Marshal(v)
+}
+
+// recordAuditBestEffort mencatat audit di luar transaksi (login, logout, purge); kegagalan hanya di-log
+// agar aksinya tidak ikut gagal karena audit log.
+func recordAuditBestEffort(c *gin.Context, entry auditEntry) {
+	if err := recordAudit(config.DB, c, entry); err != nil {
+		log.Printf("Audit: failed to record %s - %v", entry.Action, err)
+	}
+}
+
+// auditQuery membangun query audit log dari filter pada query string.
+//
+// Filter yang didukung: actor_id, action, entity_type, entity_id, from, to ("2006-01-02" atau RFC3339).
+func auditQuery(c *gin.Context) (*gorm.DB, error) {
+	query := config.DB.Model(&models.AuditLog{})
+	if actorID := c.Query("actor_id"); actorID != "" {
+		id, err := strconv.ParseUint(actorID, 10, 32)
+		if err != nil {
+			return nil, errors.New("actor_id must be a number")
+		}
+		query = query.Where("actor_id = ?", id)
+	}
+	if action := c.Query("action"); action != "" {
+		// "post." mencocokkan semua aksi pada post
+		if strings.HasSuffix(action, ".") {
+			query = query.Where("action LIKE ?", escapeLike(action)+"%")
+		} else {
+			query = query.Where("action = ?", action)
+		}
+	}
+	if entityType := c.Query("entity_type"); entityType != "" {
+		query = query.Where("entity_type = ?", entityType)
+	}
+	if entityID := c.Query("entity_id"); entityID != "" {
+		query = query.Where("entity_id = ?", entityID)
+	}
+	if from := c.Query("from"); from != "" {
+		fromTime, err := parseDateParam(from, false)
+		if err != nil {
+			return nil, errors.New("invalid 'from' date")
+		}
+		query = query.Where("created_at >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := parseDateParam(to, true)
+		if err != nil {
+			return nil, errors.New("invalid 'to' date")
+		}
+		query = query.Where("created_at <= ?", toTime)
+	}
+	return query, nil
+}
+
+// GetAuditLogs handler: GET /admin/audit — audit log terbaru lebih dulu, dengan filter dan cursor pagination
+func GetAuditLogs(c *gin.Context) {
+	limit, err := parseLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	query, err := auditQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if cursorParam := c.Query("cursor"); cursorParam != "" {
+		cursor, err := decodeCursor(cursorParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	var logs []models.AuditLog
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&logs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve audit log: " + err.Error()})
+		return
+	}
+
+	hasMore := len(logs) > limit
+	if hasMore {
+		logs = logs[:limit]
+	}
+	var nextCursor interface{}
+	if hasMore {
+		last := logs[len(logs)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"entries":     logs,
+		"next_cursor": nextCursor,
+		"has_more":    hasMore,
+		"limit":       limit,
+	})
+}
+
+// Batas baris per ekspor CSV agar request tidak melewati batas waktu serverless
+const auditExportMaxRows = 50000
+
+// ExportAuditLogs handler: GET /admin/audit/export — ekspor CSV dengan filter yang sama seperti GET /admin/audit
+func ExportAuditLogs(c *gin.Context) {
+	query, err := auditQuery(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	filename := fmt.Sprintf("audit-%s.csv", time.Now().Format("20060102-150405"))
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", `attachment; filename="`+filename+`"`)
+
+	w := csv.NewWriter(c.Writer)
+	w.Write([]string{"id", "created_at", "actor_id", "actor_name", "action", "entity_type", "entity_id", "ip", "user_agent", "before", "after"})
+
+	// Keyset pagination per 500 baris agar memori tetap kecil
+	var cursor *pageCursor
+	written := 0
+	for written < auditExportMaxRows {
+		batchQuery := query.Session(&gorm.Session{})
+		if cursor != nil {
+			batchQuery = batchQuery.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+		var batch []models.AuditLog
+		if err := batchQuery.Order("created_at DESC, id DESC").Limit(500).Find(&batch).Error; err != nil {
+			// Header sudah terkirim; tandai ekspor tidak lengkap di baris terakhir
+			log.Printf("Audit export failed after %d rows - %v", written, err)
+			w.Write([]string{"", "", "", "", "export_error", "", "", "", "", "", err.Error()})
+			break
+		}
+		for _, entry := range batch {
+			actorID := ""
+			if entry.ActorID != nil {
+				actorID = strconv.FormatUint(uint64(*entry.ActorID), 10)
+			}
+			w.Write([]string{
+				strconv.FormatUint(uint64(entry.ID), 10),
+				entry.CreatedAt.Format(time.RFC3339),
+				actorID,
+				csvSafe(entry.ActorName),
+				entry.Action,
+				entry.EntityType,
+				csvSafe(entry.EntityID),
+				entry.IP,
+				csvSafe(entry.UserAgent),
+				string(entry.Before),
+				string(entry.After),
+			})
+			written++
+		}
+		w.Flush()
+		if len(batch) < 500 || w.Error() != nil {
+			break
+		}
+		last := batch[len(batch)-1]
+		cursor = &pageCursor{CreatedAt: last.CreatedAt, ID: last.ID}
+	}
+	w.Flush()
+}
+
+// csvSafe mencegah formula injection saat CSV dibuka di spreadsheet
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune("=+-@\t\r", rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
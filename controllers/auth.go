@@ -11,11 +11,13 @@ import (
 	"github.com/gin-contrib/sessions"
 	"github.com/gin-gonic/gin"
 	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
 )
 
 type SignupInput struct {
 	Username string `json:"username" binding:"required"`
 	Password string `json:"password" binding:"required"`
+	Email    string `json:"email"` // Opsional; dikirimi link verifikasi
 }
 
 type LoginInput struct {
@@ -24,6 +26,9 @@ type LoginInput struct {
 }
 
 func Signup(c *gin.Context) {
+	if !allowRequest(c, "signup", signupRateRule) {
+		return
+	}
 	var input SignupInput
 	if err := c.ShouldBindJSON(&input); err != nil {
 		log.Printf("Signup: Bad request - %v", err) // Log error binding
@@ -37,6 +42,15 @@ func Signup(c *gin.Context) {
 
 	log.Printf("Signup: Attempting to signup user: %s", input.Username)
 
+	var email string
+	if input.Email != "" {
+		var valid bool
+		if email, valid = normalizeEmail(input.Email); !valid {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email address"})
+			return
+		}
+	}
+
 	// Periksa username unik
 	var existing models.User
 	if err := config.DB.Where("username = ?", input.Username).First(&existing).Error; err == nil {
@@ -64,11 +78,24 @@ func Signup(c *gin.Context) {
 		return
 	}
 	log.Printf("Signup: User '%s' created successfully with ID %d.", user.Username, user.ID)
+	recordAuditBestEffort(c, auditEntry{Action: "auth.signup", EntityType: "user", EntityID: user.ID, After: user, ActorID: user.ID})
+
+	// Email baru tersimpan setelah diverifikasi; kegagalan kirim tidak menggagalkan signup
+	verificationSent := false
+	if email != "" {
+		if err := sendVerificationEmail(c, user, email); err != nil {
+			log.Printf("Signup: Failed to send verification email for %s - %v", user.Username, err)
+		} else {
+			verificationSent = true
+		}
+	}
 
 	c.JSON(http.StatusCreated, gin.H{
 		"id":         user.ID,
 		"username":   user.Username,
 		"created_at": user.CreatedAt,
+
+		"verification_email_sent": verificationSent,
 	})
 }
 
@@ -85,40 +112,79 @@ func Login(c *gin.Context) {
 	input.Password = strings.TrimSpace(input.Password) // Pangkas spasi password juga
 
 	log.Printf("Login: Attempting to login user: %s", input.Username)
+	if !allowLoginAttempt(c, input.Username) {
+		return
+	}
 
 	var user models.User
 	// Tambahkan logging untuk kueri Find
 	if err := config.DB.Where("username = ?", input.Username).First(&user).Error; err != nil {
 		// Jika user tidak ditemukan, GORM akan mengembalikan gorm.ErrRecordNotFound
 		log.Printf("Login: User '%s' not found or DB error - %v", input.Username, err)
+		recordAuditBestEffort(c, auditEntry{Action: "auth.login_failed", EntityType: "user", EntityID: input.Username})
+		recordLoginFailure(c, input.Username)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
 		return
 	}
 	log.Printf("Login: User '%s' found. Comparing passwords...", user.Username)
 
 	// Compare password
-	// Log hash yang diambil dari DB dan password yang diinput
-	log.Printf("Login: Hashed from DB: '%s', Input password: '%s'", user.Password, input.Password)
 	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(input.Password)); err != nil {
 		log.Printf("Login: Password mismatch for user '%s' - %v", user.Username, err)
+		recordAuditBestEffort(c, auditEntry{Action: "auth.login_failed", EntityType: "user", EntityID: user.ID})
+		recordLoginFailure(c, input.Username)
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
 		return
 	}
 	log.Printf("Login: Password matched for user '%s'.", user.Username)
+	if user.IsSuspended() {
+		log.Printf("Login: User '%s' is suspended.", user.Username)
+		recordAuditBestEffort(c, auditEntry{Action: "auth.login_blocked", EntityType: "user", EntityID: user.ID, ActorID: user.ID})
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended", "reason": user.SuspendedReason})
+		return
+	}
+
+	// Akun dengan 2FA aktif harus menyelesaikan POST /login/2fa sebelum session dibuat.
+	// Riwayat kegagalan baru dihapus setelah kode 2FA benar agar lockout tetap melindungi kode.
+	if user.TwoFactorEnabled() {
+		startLoginChallenge(c, user)
+		return
+	}
+	recordLoginSuccess(c, user.Username)
+	startSession(c, user)
+}
+
+// startSession membuat session cookie untuk user yang sudah lolos semua langkah login
+func startSession(c *gin.Context, user models.User) {
+	if err := saveLoginSession(c, user); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
+		return
+	}
+	recordAuditBestEffort(c, auditEntry{Action: "auth.login", EntityType: "user", EntityID: user.ID, ActorID: user.ID})
+	c.JSON(http.StatusOK, gin.H{
+		"message":              "Logged in successfully",
+		"must_change_password": user.MustChangePassword,
+
+		"two_factor_setup_required": config.TwoFactorRequired(user) && !user.TwoFactorEnabled(),
+	})
+}
 
-	// Set session cookie
+// saveLoginSession menyimpan user ke session cookie (session store merotasi ID-nya)
+func saveLoginSession(c *gin.Context, user models.User) error {
 	session := sessions.Default(c)
 	session.Set("id", int(user.ID))
 	if err := session.Save(); err != nil {
 		log.Printf("Login: Failed to save session for user %s - %v", user.Username, err)
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
-		return
+		return err
 	}
 	log.Printf("Login: User '%s' logged in successfully. Session ID: %v", user.Username, user.ID)
-	c.JSON(http.StatusOK, gin.H{"message": "Logged in successfully"})
+	return nil
 }
 
 func Logout(c *gin.Context) {
+	if userID, ok := currentUserID(c); ok {
+		recordAuditBestEffort(c, auditEntry{Action: "auth.logout", EntityType: "user", EntityID: userID})
+	}
 	session := sessions.Default(c)
 	session.Clear()
 	session.Options(sessions.Options{MaxAge: -1}) // expire immediately
@@ -151,20 +217,67 @@ func GetCurrentUser(c *gin.Context) {
 		return
 	}
 
-	// Kembalikan hanya informasi yang aman untuk frontend, termasuk is_admin
+	// Kembalikan hanya informasi yang aman untuk frontend, termasuk role dan izinnya
 	c.JSON(http.StatusOK, gin.H{
-		"id":         user.ID,
-		"username":   user.Username,
-		"is_admin":   user.IsAdmin, // Tambahkan is_admin di respons
-		"created_at": user.CreatedAt,
+		"id":          user.ID,
+		"username":    user.Username,
+		"role":        user.Role,
+		"permissions": user.Role.Permissions(),
+		"is_admin":    user.IsAdmin(), // Untuk frontend lama; gunakan permissions
+		"created_at":  user.CreatedAt,
+		"email":       user.Email,
+
+		"email_verified":       user.EmailVerifiedAt != nil,
+		"must_change_password": user.MustChangePassword,
+		"two_factor_enabled":   user.TwoFactorEnabled(),
+		"two_factor_required":  config.TwoFactorRequired(user),
 	})
 }
 
+// currentUserID mengambil ID user yang diset oleh middleware.AuthRequired
+// usesBearerToken mengembalikan true jika request ini diautentikasi dengan access token (lihat middleware.AuthRequired)
+func usesBearerToken(c *gin.Context) bool {
+	return c.GetBool("bearerAuth")
+}
+
+func currentUserID(c *gin.Context) (uint, bool) {
+	uidVal, exists := c.Get("userID")
+	if !exists {
+		return 0, false
+	}
+	switch v := uidVal.(type) {
+	case uint:
+		return v, true
+	case int:
+		return uint(v), true
+	}
+	return 0, false
+}
+
+// usernameOf mengambil username untuk ditampilkan di notifikasi; kosong jika user tidak ditemukan
+func usernameOf(db *gorm.DB, userID uint) string {
+	var user models.User
+	if err := db.Select("username").First(&user, userID).Error; err != nil {
+		return ""
+	}
+	return user.Username
+}
+
+// currentUserCan memeriksa izin user yang sedang login dari role yang diset middleware.AuthRequired
+func currentUserCan(c *gin.Context, perm models.Permission) bool {
+	role, _ := c.Get("userRole")
+	r, ok := role.(models.Role)
+	return ok && r.Can(perm)
+}
+
 // controllers/auth.go (Lanjutkan di file yang sama)
 
 // GuestLogin handler: login sebagai guest
 func GuestLogin(c *gin.Context) {
-	const guestUsername = "guest" // Username khusus untuk guest
+	const guestUsername = models.GuestUsername // Username khusus untuk guest
+	if !allowRequest(c, "guest_login", guestRateRule) {
+		return
+	}
 
 	var user models.User
 	// Cari user guest
@@ -183,7 +296,7 @@ func GuestLogin(c *gin.Context) {
 			guestUser := models.User{
 				Username: guestUsername,
 				Password: string(hashedPassword),
-				IsAdmin:  false, // Guest tidak boleh jadi admin
+				Role:     models.RoleStudent, // Guest tidak boleh jadi admin
 			}
 			if err := config.DB.Create(&guestUser).Error; err != nil {
 				log.Printf("GuestLogin: Failed to create guest user in DB - %v", err)
@@ -199,16 +312,20 @@ func GuestLogin(c *gin.Context) {
 		}
 	}
 
+	if user.IsSuspended() {
+		c.JSON(http.StatusForbidden, gin.H{"error": "Guest access is suspended"})
+		return
+	}
+
 	// Set session cookie untuk user guest
 	session := sessions.Default(c)
 	session.Set("id", int(user.ID))
-	// Simpan status IsAdmin di session agar mudah diakses frontend
-	session.Set("is_admin", user.IsAdmin) // Simpan is_admin di session
 	if err := session.Save(); err != nil {
 		log.Printf("GuestLogin: Failed to save session for guest user %s - %v", user.Username, err)
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
 		return
 	}
 	log.Printf("GuestLogin: Guest user '%s' logged in successfully. Session ID: %v", user.Username, user.ID)
-	c.JSON(http.StatusOK, gin.H{"message": "Logged in as guest successfully", "is_admin": user.IsAdmin})
+	recordAuditBestEffort(c, auditEntry{Action: "auth.guest_login", EntityType: "user", EntityID: user.ID, ActorID: user.ID})
+	c.JSON(http.StatusOK, gin.H{"message": "Logged in as guest successfully", "is_admin": user.IsAdmin()})
 }
@@ -0,0 +1,365 @@
+package controllers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"time"
+
+	"filoti-backend/config"
+	"filoti-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Input untuk mengajukan klaim
+type CreateClaimInput struct {
+	Description string `json:"description" binding:"required"`
+	ProofImage  string `json:"proof_image"` // URL dari POST /uploads, opsional
+}
+
+// Input untuk menyetujui/menolak klaim
+type ReviewClaimInput struct {
+	Note string `json:"note"`
+}
+
+// CreateClaim handler: POST /posts/:id/claims — user mengajukan klaim atas barang di post
+func CreateClaim(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input CreateClaimInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := validateImageURL(config.DB, input.ProofImage); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proof_image: " + err.Error()})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.Preload("Status").First(&post, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post: " + err.Error()})
+		return
+	}
+	if !post.Status.State.IsActive() {
+		c.JSON(http.StatusConflict, gin.H{"error": "This item has already been claimed"})
+		return
+	}
+
+	var existing int64
+	config.DB.Model(&models.Claim{}).
+		Where("post_id = ? AND claimant_id = ? AND status = ?", post.ID, userID, models.ClaimPending).
+		Count(&existing)
+	if existing > 0 {
+		c.JSON(http.StatusConflict, gin.H{"error": "You already have a pending claim for this item"})
+		return
+	}
+
+	claim := models.Claim{
+		PostID:      post.ID,
+		ClaimantID:  userID,
+		Description: input.Description,
+		ProofImage:  input.ProofImage,
+		Status:      models.ClaimPending,
+	}
+
+	tx := config.DB.Begin()
+	if err := tx.Create(&claim).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create claim: " + err.Error()})
+		return
+	}
+
+	// Klaim pertama memindahkan post ke claim_pending
+	status, err := lockStatus(tx, post.ID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve status: " + err.Error()})
+		return
+	}
+	if !status.State.IsActive() {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "This item has already been claimed"})
+		return
+	}
+	if status.State != models.StateClaimPending {
+		if err := transitionPost(tx, &status, models.StateClaimPending, userID, "Klaim diajukan"); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post status: " + err.Error()})
+			return
+		}
+	}
+	event := notificationEvent{
+		Type:   models.NotificationClaimSubmitted,
+		PostID: post.ID,
+		Payload: map[string]interface{}{
+			"post_id":       post.ID,
+			"claim_id":      claim.ID,
+			"title":         post.Title,
+			"claimant_id":   userID,
+			"claimant_name": usernameOf(tx, userID),
+		},
+	}
+	if err := notifyAdmins(tx, event); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification: " + err.Error()})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Claim submitted successfully", "claim": claim})
+}
+
+// GetMyClaims handler: GET /me/claims — daftar klaim milik user yang login
+func GetMyClaims(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var claims []models.Claim
+	if err := config.DB.Preload("Post").Where("claimant_id = ?", userID).
+		Order("created_at DESC").Find(&claims).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve claims: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, claims)
+}
+
+// GetClaimQueue handler: GET /admin/claims?status=pending — antrian review untuk admin.
+// Default hanya klaim pending, diurutkan dari yang paling lama menunggu.
+func GetClaimQueue(c *gin.Context) {
+	status := models.ClaimStatus(c.DefaultQuery("status", string(models.ClaimPending)))
+	switch status {
+	case models.ClaimPending, models.ClaimApproved, models.ClaimRejected:
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "status must be 'pending', 'approved' or 'rejected'"})
+		return
+	}
+
+	query := config.DB.Preload("Post").Preload("Post.Status").Preload("Claimant").Where("status = ?", status)
+	if postID := c.Query("post_id"); postID != "" {
+		query = query.Where("post_id = ?", postID)
+	}
+
+	var claims []models.Claim
+	if err := query.Order("created_at ASC").Find(&claims).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve claims: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, claims)
+}
+
+// ApproveClaim handler: POST /admin/claims/:id/approve.
+// Menutup post atas nama pengklaim dan menolak klaim lain yang masih pending untuk post yang sama.
+func ApproveClaim(c *gin.Context) {
+	reviewClaim(c, models.ClaimApproved)
+}
+
+// RejectClaim handler: POST /admin/claims/:id/reject
+func RejectClaim(c *gin.Context) {
+	reviewClaim(c, models.ClaimRejected)
+}
+
+func reviewClaim(c *gin.Context, decision models.ClaimStatus) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input ReviewClaimInput
+	if err := c.ShouldBindJSON(&input); err != nil && !errors.Is(err, io.EOF) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	var claim models.Claim
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&claim, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Claim not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve claim: " + err.Error()})
+		return
+	}
+	if claim.Status != models.ClaimPending {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Claim has already been reviewed"})
+		return
+	}
+
+	var post models.Post
+	if err := tx.First(&post, claim.PostID).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			// Post ada di tempat sampah atau sudah di-purge; klaim tetap tersimpan sebagai arsip
+			c.JSON(http.StatusConflict, gin.H{"error": "Post of this claim has been deleted"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post: " + err.Error()})
+		return
+	}
+
+	claimBefore := claim
+	now := time.Now()
+	claim.Status = decision
+	claim.ReviewedBy = &adminID
+	claim.ReviewNote = input.Note
+	claim.ReviewedAt = &now
+	if err := tx.Save(&claim).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update claim: " + err.Error()})
+		return
+	}
+
+	// Klaim lain yang ikut ditolak karena barang sudah diserahkan
+	var autoRejected []models.Claim
+
+	status, err := lockStatus(tx, claim.PostID)
+	if err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve status: " + err.Error()})
+		return
+	}
+
+	if decision == models.ClaimApproved {
+		if !status.State.IsActive() {
+			tx.Rollback()
+			c.JSON(http.StatusConflict, gin.H{"error": "This item has already been marked as done"})
+			return
+		}
+
+		claimantID := claim.ClaimantID
+		status.ClaimerID = &claimantID
+		status.ClaimerName = usernameOf(tx, claim.ClaimantID)
+		status.ProofImage = claim.ProofImage
+		if err := transitionPost(tx, &status, models.StateReturned, adminID, "Klaim disetujui"); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post status: " + err.Error()})
+			return
+		}
+
+		// Laporan kehilangan milik pengklaim yang tertaut ke post ini ikut selesai
+		if err := tx.Model(&models.LostReport{}).
+			Where("matched_post_id = ? AND reporter_id = ? AND status = ?", claim.PostID, claim.ClaimantID, models.LostReportMatched).
+			Update("status", models.LostReportResolved).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to resolve lost report: " + err.Error()})
+			return
+		}
+
+		if err := tx.Where("post_id = ? AND status = ? AND id <> ?", claim.PostID, models.ClaimPending, claim.ID).
+			Find(&autoRejected).Error; err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve other claims: " + err.Error()})
+			return
+		}
+		if len(autoRejected) > 0 {
+			if err := tx.Model(&models.Claim{}).
+				Where("post_id = ? AND status = ? AND id <> ?", claim.PostID, models.ClaimPending, claim.ID).
+				Updates(map[string]interface{}{
+					"status":      models.ClaimRejected,
+					"reviewed_by": adminID,
+					"review_note": "Barang sudah diserahkan kepada pengklaim lain",
+					"reviewed_at": now,
+				}).Error; err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update other claims: " + err.Error()})
+				return
+			}
+		}
+	} else if status.State == models.StateClaimPending {
+		// Tidak ada klaim lain yang menunggu: kembalikan post ke state sebelum claim_pending
+		var remaining int64
+		tx.Model(&models.Claim{}).Where("post_id = ? AND status = ?", claim.PostID, models.ClaimPending).Count(&remaining)
+		if remaining == 0 {
+			if err := transitionPost(tx, &status, stateBeforeClaim(tx, claim.PostID), adminID, "Klaim ditolak"); err != nil {
+				tx.Rollback()
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post status: " + err.Error()})
+				return
+			}
+		}
+	}
+
+	action := "claim.reject"
+	notificationType := models.NotificationClaimRejected
+	if decision == models.ClaimApproved {
+		action = "claim.approve"
+		notificationType = models.NotificationClaimApproved
+	}
+	if err := recordAudit(tx, c, auditEntry{Action: action, EntityType: "claim", EntityID: claim.ID, Before: claimBefore, After: claim}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record audit log: " + err.Error()})
+		return
+	}
+
+	event := notificationEvent{
+		Type:   notificationType,
+		PostID: post.ID,
+		Payload: map[string]interface{}{
+			"post_id":  post.ID,
+			"claim_id": claim.ID,
+			"title":    post.Title,
+			"note":     input.Note,
+			"actor_id": adminID,
+		},
+	}
+	if err := notifyUsers(tx, []uint{claim.ClaimantID}, event); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification: " + err.Error()})
+		return
+	}
+	for _, other := range autoRejected {
+		rejected := notificationEvent{
+			Type:   models.NotificationClaimRejected,
+			PostID: post.ID,
+			Payload: map[string]interface{}{
+				"post_id":  post.ID,
+				"claim_id": other.ID,
+				"title":    post.Title,
+				"note":     "Barang sudah diserahkan kepada pengklaim lain",
+				"actor_id": adminID,
+			},
+		}
+		if err := notifyUsers(tx, []uint{other.ClaimantID}, rejected); err != nil {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification: " + err.Error()})
+			return
+		}
+	}
+
+	tx.Commit()
+
+	message := "Claim rejected"
+	if decision == models.ClaimApproved {
+		message = "Claim approved and post marked as done"
+	}
+	c.JSON(http.StatusOK, gin.H{"message": message, "claim": claim})
+}
+
+// stateBeforeClaim mencari state post sebelum masuk claim_pending dari riwayat status
+func stateBeforeClaim(tx *gorm.DB, postID uint) models.PostState {
+	var last models.StatusHistory
+	err := tx.Where("post_id = ? AND to_state = ?", postID, models.StateClaimPending).
+		Order("id DESC").First(&last).Error
+	if err != nil || !models.CanTransition(models.StateClaimPending, last.FromState) {
+		return models.StateInStorage
+	}
+	return last.FromState
+}
@@ -0,0 +1,73 @@
+package controllers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"filoti-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// postETag menggabungkan versi post dan versi status-nya,
+// sehingga berubah setiap kali isi post atau state-nya berubah.
+// post.Status harus sudah dimuat.
+func postETag(post models.Post) string {
+	return fmt.Sprintf(`"%d-%d.%d"`, post.ID, post.Version, post.Status.Version)
+}
+
+// etagMatches memeriksa apakah salah satu nilai pada header If-Match/If-None-Match cocok.
+// Prefix W/ diabaikan karena beberapa proxy melemahkan ETag saat mengompresi respons.
+func etagMatches(header, current string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == current {
+			return true
+		}
+	}
+	return false
+}
+
+// checkIfMatch mewajibkan header If-Match yang sesuai dengan versi saat ini.
+// Mengembalikan false (dan sudah menulis respons 428/412) jika request harus dihentikan.
+func checkIfMatch(c *gin.Context, current string) bool {
+	header := c.GetHeader("If-Match")
+	if header == "" {
+		c.JSON(http.StatusPreconditionRequired, gin.H{"error": "If-Match header is required; use the ETag from GET /posts/:id", "etag": current})
+		return false
+	}
+	if !etagMatches(header, current) {
+		c.Header("ETag", current)
+		c.JSON(http.StatusPreconditionFailed, gin.H{"error": "Post has been modified by someone else; reload it and try again", "etag": current})
+		return false
+	}
+	return true
+}
+
+// lockPostForWrite membaca post beserta status-nya dengan FOR UPDATE (selalu post dulu, lalu status)
+func lockPostForWrite(tx *gorm.DB, postID uint) (models.Post, error) {
+	var post models.Post
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, postID).Error; err != nil {
+		return post, err
+	}
+	status, err := lockStatus(tx, post.ID)
+	if err != nil {
+		return post, err
+	}
+	post.Status = status
+	return post, nil
+}
+
+// parsePostID membaca :id sebagai angka; menulis 400 dan mengembalikan false jika tidak valid
+func parsePostID(c *gin.Context) (uint, bool) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Post ID"})
+		return 0, false
+	}
+	return uint(id), true
+}
@@ -0,0 +1,371 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"filoti-backend/config"
+	"filoti-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Input untuk membuat laporan kehilangan
+type CreateLostReportInput struct {
+	Title            string    `json:"title" binding:"required"`
+	Description      string    `json:"description" binding:"required"`
+	ItemType         string    `json:"itemType" binding:"required"`
+	LastSeenLocation string    `json:"last_seen_location" binding:"required"`
+	LostFrom         time.Time `json:"lost_from" binding:"required"`
+	LostUntil        time.Time `json:"lost_until" binding:"required"`
+	ImageURL         string    `json:"image_url"`
+	ContactInfo      string    `json:"contact_info"`
+}
+
+// Input untuk menautkan laporan kehilangan ke post barang temuan
+type LinkLostReportInput struct {
+	PostID uint `json:"post_id" binding:"required"`
+}
+
+// CreateLostReport handler: POST /lost-reports — user melaporkan barang yang hilang
+func CreateLostReport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input CreateLostReportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if input.LostUntil.Before(input.LostFrom) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lost_until must not be before lost_from"})
+		return
+	}
+	if input.LostFrom.After(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "lost_from cannot be in the future"})
+		return
+	}
+	if err := validateImageURL(config.DB, input.ImageURL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image_url: " + err.Error()})
+		return
+	}
+
+	report := models.LostReport{
+		ReporterID:       userID,
+		Title:            input.Title,
+		Description:      input.Description,
+		ItemType:         input.ItemType,
+		LastSeenLocation: input.LastSeenLocation,
+		LostFrom:         input.LostFrom,
+		LostUntil:        input.LostUntil,
+		ImageURL:         input.ImageURL,
+		ContactInfo:      input.ContactInfo,
+		Status:           models.LostReportOpen,
+	}
+
+	tx := config.DB.Begin()
+	if err := tx.Create(&report).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create lost report: " + err.Error()})
+		return
+	}
+	event := notificationEvent{
+		Type: models.NotificationLostReportCreated,
+		Payload: map[string]interface{}{
+			"lost_report_id": report.ID,
+			"lost_title":     report.Title,
+			"location":       report.LastSeenLocation,
+			"item_type":      report.ItemType,
+			"reporter_id":    userID,
+			"reporter_name":  usernameOf(tx, userID),
+		},
+	}
+	if err := notifyAdmins(tx, event); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification: " + err.Error()})
+		return
+	}
+	tx.Commit()
+
+	suggestMatchesForLostReport(config.DB, report)
+
+	c.JSON(http.StatusCreated, gin.H{"message": "Lost report created successfully", "lost_report": report})
+}
+
+// GetLostReports handler: GET /lost-reports — admin melihat semua laporan, user biasa hanya miliknya.
+// Mendukung ?status=, ?itemType=, ?limit= dan ?cursor= seperti GetPosts (urut terbaru).
+func GetLostReports(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	limit, err := parseLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := config.DB.Model(&models.LostReport{})
+	if !currentUserCan(c, models.PermLostReportsRead) || c.Query("mine") == "true" {
+		query = query.Where("reporter_id = ?", userID)
+	}
+	if status := c.Query("status"); status != "" {
+		query = query.Where("status = ?", status)
+	}
+	if itemType := c.Query("itemType"); itemType != "" {
+		query = query.Where("item_type = ?", itemType)
+	}
+
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count lost reports: " + err.Error()})
+		return
+	}
+	if cursorParam := c.Query("cursor"); cursorParam != "" {
+		cursor, err := decodeCursor(cursorParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	var reports []models.LostReport
+	if err := query.Preload("Reporter").Order("created_at DESC, id DESC").Limit(limit + 1).Find(&reports).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve lost reports: " + err.Error()})
+		return
+	}
+	hasMore := len(reports) > limit
+	if hasMore {
+		reports = reports[:limit]
+	}
+	var nextCursor interface{}
+	if hasMore {
+		last := reports[len(reports)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+	if reports == nil {
+		reports = []models.LostReport{}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"lost_reports": reports,
+		"next_cursor":  nextCursor,
+		"has_more":     hasMore,
+		"total":        total,
+		"limit":        limit,
+	})
+}
+
+// GetLostReportByID handler: GET /lost-reports/:id — hanya pelapor atau admin
+func GetLostReportByID(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var report models.LostReport
+	if err := config.DB.Preload("Reporter").Preload("MatchedPost").Preload("MatchedPost.Status").
+		First(&report, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lost report not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve lost report: " + err.Error()})
+		return
+	}
+	if report.ReporterID != userID && !currentUserCan(c, models.PermLostReportsRead) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lost report not found"})
+		return
+	}
+	c.JSON(http.StatusOK, report)
+}
+
+// CloseLostReport handler: POST /lost-reports/:id/close — pelapor/admin menutup laporan.
+// Laporan yang sudah tertaut ke post yang diserahkan (state returned) ditandai resolved, selain itu closed.
+func CloseLostReport(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	tx := config.DB.Begin()
+	var report models.LostReport
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&report, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lost report not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve lost report: " + err.Error()})
+		return
+	}
+	if report.ReporterID != userID && !currentUserCan(c, models.PermLostReportsLink) {
+		tx.Rollback()
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lost report not found"})
+		return
+	}
+	if report.Status == models.LostReportResolved || report.Status == models.LostReportClosed {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Lost report is already " + string(report.Status)})
+		return
+	}
+
+	newStatus := models.LostReportClosed
+	if report.Status == models.LostReportMatched && report.MatchedPostID != nil {
+		var status models.Status
+		err := tx.Where("post_id = ?", *report.MatchedPostID).First(&status).Error
+		if err != nil && err != gorm.ErrRecordNotFound {
+			tx.Rollback()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post status: " + err.Error()})
+			return
+		}
+		if err == nil && status.State == models.StateReturned {
+			newStatus = models.LostReportResolved
+		}
+	}
+	if err := tx.Model(&report).Update("status", newStatus).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to close lost report: " + err.Error()})
+		return
+	}
+	if err := recordAudit(tx, c, auditEntry{Action: "lost_report.close", EntityType: "lost_report", EntityID: report.ID, Before: report.Status, After: newStatus}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record audit log: " + err.Error()})
+		return
+	}
+	tx.Commit()
+	report.Status = newStatus
+	c.JSON(http.StatusOK, gin.H{"message": "Lost report closed", "lost_report": report})
+}
+
+// LinkLostReport handler: POST /admin/lost-reports/:id/link — admin menautkan laporan kehilangan
+// ke post barang temuan yang cocok, lalu memberi tahu pelapor.
+func LinkLostReport(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input LinkLostReportInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var post models.Post
+	if err := config.DB.First(&post, input.PostID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post: " + err.Error()})
+		return
+	}
+
+	tx := config.DB.Begin()
+	var report models.LostReport
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&report, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lost report not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve lost report: " + err.Error()})
+		return
+	}
+	if report.Status == models.LostReportResolved || report.Status == models.LostReportClosed {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Lost report is already " + string(report.Status)})
+		return
+	}
+
+	reportBefore := report
+	now := time.Now()
+	report.Status = models.LostReportMatched
+	report.MatchedPostID = &post.ID
+	report.MatchedBy = &adminID
+	report.MatchedAt = &now
+	if err := tx.Save(&report).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to link lost report: " + err.Error()})
+		return
+	}
+	if err := recordAudit(tx, c, auditEntry{Action: "lost_report.link", EntityType: "lost_report", EntityID: report.ID, Before: reportBefore, After: report}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record audit log: " + err.Error()})
+		return
+	}
+
+	event := notificationEvent{
+		Type:   models.NotificationLostReportMatched,
+		PostID: post.ID,
+		Payload: map[string]interface{}{
+			"lost_report_id": report.ID,
+			"lost_title":     report.Title,
+			"post_id":        post.ID,
+			"title":          post.Title,
+			"actor_id":       adminID,
+		},
+	}
+	if err := notifyUsers(tx, []uint{report.ReporterID}, event); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification: " + err.Error()})
+		return
+	}
+	tx.Commit()
+
+	c.JSON(http.StatusOK, gin.H{"message": "Lost report linked to post", "lost_report": report})
+}
+
+// UnlinkLostReport handler: DELETE /admin/lost-reports/:id/link — membatalkan tautan yang salah
+func UnlinkLostReport(c *gin.Context) {
+	tx := config.DB.Begin()
+	var report models.LostReport
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&report, c.Param("id")).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lost report not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve lost report: " + err.Error()})
+		return
+	}
+	if report.Status != models.LostReportMatched {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Lost report is not linked to a post"})
+		return
+	}
+
+	reportBefore := report
+	if err := tx.Model(&report).Updates(map[string]interface{}{
+		"status":          models.LostReportOpen,
+		"matched_post_id": nil,
+		"matched_by":      nil,
+		"matched_at":      nil,
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to unlink lost report: " + err.Error()})
+		return
+	}
+	report.Status = models.LostReportOpen
+	report.MatchedPostID = nil
+	report.MatchedBy = nil
+	report.MatchedAt = nil
+	if err := recordAudit(tx, c, auditEntry{Action: "lost_report.unlink", EntityType: "lost_report", EntityID: report.ID, Before: reportBefore, After: report}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record audit log: " + err.Error()})
+		return
+	}
+	tx.Commit()
+	c.JSON(http.StatusOK, gin.H{"message": "Lost report unlinked", "lost_report": report})
+}
@@ -0,0 +1,227 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"sort"
+	"time"
+
+	"filoti-backend/config"
+	"filoti-backend/matching"
+	"filoti-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Batas kandidat yang dibandingkan per pencarian
+const (
+	matchCandidateLimit = 500
+	matchLookback       = 90 * 24 * time.Hour
+)
+
+type postMatch struct {
+	Post  models.Post
+	Score matching.Score
+}
+
+type lostReportMatch struct {
+	LostReport models.LostReport
+	Score      matching.Score
+}
+
+func foundFromPost(p models.Post) matching.Found {
+	return matching.Found{
+		Title:       p.Title,
+		Description: p.Keterangan,
+		Location:    p.Ruangan,
+		Category:    p.ItemType,
+		FoundAt:     p.CreatedAt,
+	}
+}
+
+func lostFromReport(r models.LostReport) matching.Lost {
+	return matching.Lost{
+		Title:       r.Title,
+		Description: r.Description,
+		Location:    r.LastSeenLocation,
+		Category:    r.ItemType,
+		LostFrom:    r.LostFrom,
+		LostUntil:   r.LostUntil,
+	}
+}
+
+// matchLostReportsForPost memberi skor laporan kehilangan yang masih open terhadap post
+func matchLostReportsForPost(db *gorm.DB, post models.Post) ([]lostReportMatch, error) {
+	var reports []models.LostReport
+	if err := db.Where("status = ? AND lost_from <= ? AND created_at >= ?",
+		models.LostReportOpen, post.CreatedAt.Add(12*time.Hour), post.CreatedAt.Add(-matchLookback)).
+		Order("created_at DESC").Limit(matchCandidateLimit).Find(&reports).Error; err != nil {
+		return nil, err
+	}
+
+	found := foundFromPost(post)
+	var matches []lostReportMatch
+	for _, r := range reports {
+		score := matching.Compare(found, lostFromReport(r))
+		if score.Total >= matching.MinScore {
+			matches = append(matches, lostReportMatch{LostReport: r, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score.Total > matches[j].Score.Total })
+	return matches, nil
+}
+
+// matchPostsForLostReport memberi skor post yang masih aktif terhadap laporan kehilangan
+func matchPostsForLostReport(db *gorm.DB, report models.LostReport) ([]postMatch, error) {
+	var posts []models.Post
+	if err := db.Preload("Status").
+		Joins("JOIN statuses ON statuses.post_id = posts.id AND statuses.status = ?", 1).
+		Where("posts.created_at >= ?", report.LostFrom.Add(-12*time.Hour)).
+		Order("posts.created_at DESC").Limit(matchCandidateLimit).Find(&posts).Error; err != nil {
+		return nil, err
+	}
+
+	lost := lostFromReport(report)
+	var matches []postMatch
+	for _, p := range posts {
+		score := matching.Compare(foundFromPost(p), lost)
+		if score.Total >= matching.MinScore {
+			matches = append(matches, postMatch{Post: p, Score: score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score.Total > matches[j].Score.Total })
+	return matches, nil
+}
+
+// notifyMatch mengirim notifikasi ke pelapor untuk pasangan berskor tinggi, sekali per pasangan
+func notifyMatch(db *gorm.DB, post models.Post, report models.LostReport, score matching.Score) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		suggestion := models.MatchSuggestion{PostID: post.ID, LostReportID: report.ID, Score: score.Total}
+		result := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&suggestion)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return nil // sudah pernah diberitahukan
+		}
+		return notifyUsers(tx, []uint{report.ReporterID}, notificationEvent{
+			Type:   models.NotificationMatchSuggested,
+			PostID: post.ID,
+			Payload: map[string]interface{}{
+				"post_id":        post.ID,
+				"title":          post.Title,
+				"ruangan":        post.Ruangan,
+				"lost_report_id": report.ID,
+				"lost_title":     report.Title,
+				"score":          score.Total,
+			},
+		})
+	})
+}
+
+// suggestMatchesForPost dijalankan setelah post baru di-commit. Error hanya di-log
+// karena pencocokan tidak boleh menggagalkan pembuatan post.
+func suggestMatchesForPost(db *gorm.DB, post models.Post) {
+	matches, err := matchLostReportsForPost(db, post)
+	if err != nil {
+		log.Printf("suggestMatchesForPost: failed to score post %d - %v", post.ID, err)
+		return
+	}
+	for _, m := range matches {
+		if m.Score.Total < matching.HighConfidence {
+			break // terurut menurun
+		}
+		if err := notifyMatch(db, post, m.LostReport, m.Score); err != nil {
+			log.Printf("suggestMatchesForPost: failed to notify lost report %d - %v", m.LostReport.ID, err)
+		}
+	}
+}
+
+// suggestMatchesForLostReport dijalankan setelah laporan kehilangan baru di-commit
+func suggestMatchesForLostReport(db *gorm.DB, report models.LostReport) {
+	matches, err := matchPostsForLostReport(db, report)
+	if err != nil {
+		log.Printf("suggestMatchesForLostReport: failed to score lost report %d - %v", report.ID, err)
+		return
+	}
+	for _, m := range matches {
+		if m.Score.Total < matching.HighConfidence {
+			break
+		}
+		if err := notifyMatch(db, m.Post, report, m.Score); err != nil {
+			log.Printf("suggestMatchesForLostReport: failed to notify lost report %d - %v", report.ID, err)
+		}
+	}
+}
+
+// GetPostMatches handler: GET /posts/:id/matches — admin melihat laporan kehilangan yang
+// paling mungkin cocok dengan barang temuan ini, lengkap dengan rincian skor
+func GetPostMatches(c *gin.Context) {
+	var post models.Post
+	if err := config.DB.First(&post, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post: " + err.Error()})
+		return
+	}
+
+	matches, err := matchLostReportsForPost(config.DB, post)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute matches: " + err.Error()})
+		return
+	}
+
+	results := []gin.H{}
+	for _, m := range matches {
+		results = append(results, gin.H{
+			"lost_report":     m.LostReport,
+			"score":           m.Score,
+			"high_confidence": m.Score.Total >= matching.HighConfidence,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"post_id": post.ID, "matches": results})
+}
+
+// GetLostReportMatches handler: GET /lost-reports/:id/matches — pelapor/admin melihat
+// barang temuan yang mungkin cocok dengan laporannya
+func GetLostReportMatches(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var report models.LostReport
+	if err := config.DB.First(&report, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Lost report not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve lost report: " + err.Error()})
+		return
+	}
+	if report.ReporterID != userID && !currentUserCan(c, models.PermLostReportsRead) {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Lost report not found"})
+		return
+	}
+
+	matches, err := matchPostsForLostReport(config.DB, report)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to compute matches: " + err.Error()})
+		return
+	}
+
+	results := []gin.H{}
+	for _, m := range matches {
+		results = append(results, gin.H{
+			"post":            postListItem(m.Post),
+			"score":           m.Score,
+			"high_confidence": m.Score.Total >= matching.HighConfidence,
+		})
+	}
+	c.JSON(http.StatusOK, gin.H{"lost_report_id": report.ID, "matches": results})
+}
@@ -2,82 +2,242 @@
 package controllers
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/http"
-	"strings"
 	"time" // Untuk formatting waktu jika diperlukan
 
 	"filoti-backend/config"
 	"filoti-backend/models"
+	"filoti-backend/realtime"
 
 	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
 )
 
-// GetNotifications handler: Mengambil notifikasi untuk admin yang sedang login
+// GetNotifications handler: Mengambil notifikasi milik user yang sedang login, terbaru lebih dulu.
+//
+// Query yang didukung:
+//   - unread=true: hanya yang belum dibaca
+//   - limit: jumlah notifikasi per halaman (default 20, maksimum 100)
+//   - cursor: nilai next_cursor dari respons sebelumnya
 func GetNotifications(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	limit, err := parseLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := config.DB.Preload("Post").Where("user_id = ?", userID)
+	if c.Query("unread") == "true" {
+		query = query.Where("is_read = ?", false)
+	}
+	if cursorParam := c.Query("cursor"); cursorParam != "" {
+		cursor, err := decodeCursor(cursorParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("(created_at, id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
 
 	var notifications []models.Notification
-	// Mengambil semua notifikasi yang terkait dengan Post yang di-upload oleh admin ini,
-	// atau semua notifikasi jika notifikasi tidak terkait langsung dengan UserID admin.
-	// Karena UserID sudah dihapus dari Notification, kita tidak bisa filter berdasarkan itu.
-	// Jika notifikasi ini adalah 'untuk semua admin' atau 'terkait dengan post yang admin buat',
-	// Anda perlu preload Post dan filter berdasarkan itu jika perlu.
-	// Untuk saat ini, saya akan mengambil semua notifikasi, karena UserID tidak ada di model Notification.
-	// Jika notifikasi ditujukan ke admin berdasarkan post yang mereka buat, Anda butuh relasi ke admin di Post.
-
-	// Skenario 1: Notifikasi untuk semua admin (terkait semua post)
-	// if err := config.DB.Find(&notifications).Error; err != nil {
-	//     c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications"})
-	//     return
-	// }
-
-	// Skenario 2 (Lebih realistis): Notifikasi yang relevan untuk admin yang login
-	// Ini akan membutuhkan `AdminID` di `models.Post` dan relasi di sana.
-	// Atau, jika notifikasi adalah untuk setiap post, dan semua admin melihatnya:
-	if err := config.DB.Preload("Post").Find(&notifications).Error; err != nil {
+	if err := query.Order("created_at DESC, id DESC").Limit(limit + 1).Find(&notifications).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to fetch notifications: " + err.Error()})
 		return
 	}
 
+	hasMore := len(notifications) > limit
+	if hasMore {
+		notifications = notifications[:limit]
+	}
+
 	// Format notifikasi untuk respons frontend
-	var notificationsToReturn []gin.H
+	notificationsToReturn := make([]gin.H, 0, len(notifications))
 	for _, notif := range notifications {
-		// Tentukan 'type' notifikasi berdasarkan pesan atau logika lain
-		// Ini adalah contoh sederhana, Anda mungkin perlu logika yang lebih canggih
-		notificationType := "info"
-		iconColor := "bg-blue-500" // Default color
-		if notif.IsRead {
-			iconColor = "bg-gray-400" // Warna berbeda jika sudah dibaca
+		notificationsToReturn = append(notificationsToReturn, notificationResponse(notif))
+	}
+
+	var nextCursor interface{}
+	if hasMore {
+		last := notifications[len(notifications)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"notifications": notificationsToReturn,
+		"next_cursor":   nextCursor,
+		"has_more":      hasMore,
+		"limit":         limit,
+	})
+}
+
+// notificationResponse memformat satu notifikasi (dipakai juga oleh stream SSE)
+func notificationResponse(notif models.Notification) gin.H {
+	postTitle := ""
+	if notif.Post != nil {
+		postTitle = notif.Post.Title
+	}
+
+	return gin.H{
+		"id":         notif.ID,
+		"post_id":    notif.PostID,
+		"type":       notif.Type, // Frontend menentukan ikon/warna dari type
+		"payload":    notif.Payload,
+		"message":    notificationText(notif), // Ini akan menjadi 'text' di frontend
+		"is_read":    notif.IsRead,
+		"read_at":    notif.ReadAt,
+		"created_at": notif.CreatedAt,
+		"time":       formatTimeAgo(notif.CreatedAt), // Format waktu untuk frontend
+		"post_title": postTitle,
+	}
+}
+
+// GetUnreadNotificationCount handler: jumlah notifikasi yang belum dibaca (untuk badge lonceng)
+func GetUnreadNotificationCount(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var count int64
+	if err := config.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Count(&count).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count notifications: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"unread": count})
+}
+
+// MarkNotificationRead handler: menandai satu notifikasi milik user sebagai sudah dibaca
+func MarkNotificationRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var notification models.Notification
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&notification).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+			return
 		}
-		if contains(notif.Message, "baru dibuat") { // Contoh deteksi tipe
-			notificationType = "new_post"
-			iconColor = "bg-green-500"
-		} else if contains(notif.Message, "klaim") || contains(notif.Message, "ambil") {
-			notificationType = "claim"
-			iconColor = "bg-purple-500"
-		} else if contains(notif.Message, "update") || contains(notif.Message, "status") {
-			notificationType = "update"
-			iconColor = "bg-orange-500"
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve notification: " + err.Error()})
+		return
+	}
+
+	if !notification.IsRead {
+		now := time.Now()
+		if err := config.DB.Model(&notification).Updates(map[string]interface{}{
+			"is_read": true,
+			"read_at": now,
+		}).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notification: " + err.Error()})
+			return
 		}
+		notification.IsRead = true
+		notification.ReadAt = &now
+	}
 
-		notificationsToReturn = append(notificationsToReturn, gin.H{
-			"id":         notif.ID,
-			"post_id":    notif.PostID,
-			"message":    notif.Message, // Ini akan menjadi 'text' di frontend
-			"is_read":    notif.IsRead,
-			"created_at": notif.CreatedAt,
-			"time":       formatTimeAgo(notif.CreatedAt), // Format waktu untuk frontend
-			"type":       notificationType,               // Tambahkan 'type' untuk ikon
-			"iconColor":  iconColor,                      // Tambahkan 'iconColor'
-			"post_title": notif.Post.Title,               // Jika preload "Post" berhasil
-		})
+	c.JSON(http.StatusOK, gin.H{"message": "Notification marked as read", "notification": notification})
+}
+
+// MarkAllNotificationsRead handler: menandai semua notifikasi user sebagai sudah dibaca
+func MarkAllNotificationsRead(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
 	}
 
-	if notificationsToReturn == nil {
-		notificationsToReturn = []gin.H{}
+	result := config.DB.Model(&models.Notification{}).
+		Where("user_id = ? AND is_read = ?", userID, false).
+		Updates(map[string]interface{}{"is_read": true, "read_at": time.Now()})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update notifications: " + result.Error.Error()})
+		return
 	}
+	c.JSON(http.StatusOK, gin.H{"message": "All notifications marked as read", "updated": result.RowsAffected})
+}
 
-	c.JSON(http.StatusOK, notificationsToReturn)
+// DeleteNotification handler: menghapus notifikasi milik user
+func DeleteNotification(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	result := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.Notification{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete notification: " + result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Notification not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Notification deleted"})
+}
+
+// notifyUsers membuat satu baris notifikasi untuk setiap penerima (dalam transaksi tx)
+func notifyUsers(tx *gorm.DB, recipientIDs []uint, event notificationEvent) error {
+	if len(recipientIDs) == 0 {
+		return nil
+	}
+	payload, err := json.Marshal(event.Payload)
+	if err != nil {
+		return err
+	}
+	message := renderNotificationMessage(event.Type, event.Payload, string(event.Type))
+
+	var postID *uint
+	if event.PostID != 0 {
+		postID = &event.PostID
+	}
+
+	notifications := make([]models.Notification, 0, len(recipientIDs))
+	ids := make([]uint, 0, len(recipientIDs))
+	for _, uid := range recipientIDs {
+		notifications = append(notifications, models.Notification{
+			UserID:  uid,
+			PostID:  postID,
+			Type:    event.Type,
+			Payload: payload,
+			Message: message,
+			IsRead:  false,
+		})
+	}
+	if err := tx.Create(&notifications).Error; err != nil {
+		return err
+	}
+	for _, n := range notifications {
+		ids = append(ids, n.ID)
+	}
+
+	// NOTIFY di dalam transaksi baru dikirim PostgreSQL saat commit,
+	// jadi stream SSE hanya menerima notifikasi yang benar-benar tersimpan.
+	return tx.Exec(
+		"SELECT pg_notify(?, json_build_object('id', id, 'user_id', user_id)::text) FROM notifications WHERE id IN ?",
+		realtime.NotificationChannel, ids,
+	).Error
+}
+
+// notifyAdmins mengirim notifikasi ke semua petugas yang role-nya menerima notifikasi meja lost & found
+func notifyAdmins(tx *gorm.DB, event notificationEvent) error {
+	var adminIDs []uint
+	if err := tx.Model(&models.User{}).Where("role IN ?", models.RolesWith(models.PermDeskNotifications)).Pluck("id", &adminIDs).Error; err != nil {
+		return err
+	}
+	return notifyUsers(tx, adminIDs, event)
 }
 
 // Fungsi helper untuk format waktu (sesuaikan jika perlu)
@@ -95,14 +255,3 @@ func formatTimeAgo(t time.Time) string {
 	}
 	return t.Format("02 Jan 2006") // Format tanggal jika lebih lama
 }
-
-// Fungsi helper sederhana untuk contains string
-func contains(s, substr string) bool {
-	return strings.Contains(strings.ToLower(s), strings.ToLower(substr))
-}
-
-// Pastikan Anda import "fmt" dan "strings"
-// import (
-// 	"fmt"
-// 	"strings"
-// )
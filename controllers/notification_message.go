@@ -0,0 +1,75 @@
+package controllers
+
+import (
+	"bytes"
+	"encoding/json"
+	"log"
+	"text/template"
+
+	"filoti-backend/models"
+)
+
+// Template pesan untuk setiap jenis notifikasi. Payload event tersedia sebagai map,
+// jadi ubah kata-kata di sini tanpa perlu menyentuh handler yang membuat notifikasinya.
+var notificationTemplates = map[models.NotificationType]*template.Template{
+	models.NotificationPostCreated: template.Must(template.New("post_created").Parse(
+		`Post baru dibuat oleh {{.actor_name}}: {{.title}}`)),
+	models.NotificationPostDone: template.Must(template.New("post_done").Parse(
+		`Laporan '{{.title}}' telah diselesaikan oleh {{.actor_name}}{{if .claimer_name}} (diambil oleh {{.claimer_name}}){{end}}`)),
+	models.NotificationPostUpdated: template.Must(template.New("post_updated").Parse(
+		`Post '{{.title}}' diperbarui oleh {{.actor_name}}`)),
+	models.NotificationPostDeleted: template.Must(template.New("post_deleted").Parse(
+		`Post '{{.title}}' dihapus oleh {{.actor_name}}`)),
+	models.NotificationPostRestored: template.Must(template.New("post_restored").Parse(
+		`Post '{{.title}}' dipulihkan dari tempat sampah oleh {{.actor_name}}`)),
+	models.NotificationPostStateChanged: template.Must(template.New("post_state_changed").Parse(
+		`Status '{{.title}}' berubah dari {{.from}} menjadi {{.to}} oleh {{.actor_name}}`)),
+	models.NotificationClaimSubmitted: template.Must(template.New("claim_submitted").Parse(
+		`Klaim baru dari {{.claimant_name}} untuk '{{.title}}'`)),
+	models.NotificationClaimApproved: template.Must(template.New("claim_approved").Parse(
+		`Klaim Anda untuk '{{.title}}' disetujui{{if .note}}: {{.note}}{{end}}`)),
+	models.NotificationClaimRejected: template.Must(template.New("claim_rejected").Parse(
+		`Klaim Anda untuk '{{.title}}' ditolak{{if .note}}: {{.note}}{{end}}`)),
+	models.NotificationLostReportCreated: template.Must(template.New("lost_report_created").Parse(
+		`Laporan kehilangan baru dari {{.reporter_name}}: {{.lost_title}} (terakhir di {{.location}})`)),
+	models.NotificationLostReportMatched: template.Must(template.New("lost_report_matched").Parse(
+		`Barang yang cocok dengan laporan kehilangan '{{.lost_title}}' ditemukan: {{.title}}. Silakan ajukan klaim`)),
+	models.NotificationMatchSuggested: template.Must(template.New("match_suggested").Parse(
+		`Barang temuan '{{.title}}' di {{.ruangan}} mungkin milik Anda (cocok dengan laporan '{{.lost_title}}')`)),
+	models.NotificationSubscriptionMatch: template.Must(template.New("subscription_match").Parse(
+		`Post baru cocok dengan pencarian tersimpan '{{.subscription_name}}': {{.title}} ({{.ruangan}})`)),
+}
+
+// notificationEvent adalah satu kejadian yang akan di-fan-out ke beberapa penerima
+type notificationEvent struct {
+	Type    models.NotificationType
+	PostID  uint
+	Payload map[string]interface{}
+}
+
+// renderNotificationMessage merender pesan dari template jenis notifikasi.
+// fallback dipakai untuk jenis tanpa template (misalnya notifikasi lama).
+func renderNotificationMessage(t models.NotificationType, payload map[string]interface{}, fallback string) string {
+	tmpl, ok := notificationTemplates[t]
+	if !ok || payload == nil {
+		return fallback
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, payload); err != nil {
+		log.Printf("renderNotificationMessage: failed to render %s - %v", t, err)
+		return fallback
+	}
+	return buf.String()
+}
+
+// notificationText merender ulang pesan notifikasi yang tersimpan dari payload-nya
+func notificationText(n models.Notification) string {
+	if len(n.Payload) == 0 {
+		return n.Message
+	}
+	var payload map[string]interface{}
+	if err := json.Unmarshal(n.Payload, &payload); err != nil {
+		return n.Message
+	}
+	return renderNotificationMessage(n.Type, payload, n.Message)
+}
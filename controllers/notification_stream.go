@@ -0,0 +1,173 @@
+package controllers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"filoti-backend/config"
+	"filoti-backend/models"
+	"filoti-backend/realtime"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	streamKeepAlive   = 25 * time.Second
+	streamBacklogSize = 100
+
+	// ID notifikasi diambil dari sequence sebelum commit, jadi notifikasi ber-ID lebih kecil bisa
+	// commit setelah ID yang lebih besar sudah terkirim. Notifikasi yang dibuat dalam jendela ini
+	// dibaca ulang saat (re)connect dan resync; duplikat dalam satu koneksi disaring, klien yang
+	// reconnect bisa menerima ulang event dengan id yang sama dan harus mengabaikannya.
+	streamReplayGrace = 30 * time.Second
+)
+
+// StreamNotifications handler: GET /notifications/stream — Server-Sent Events.
+// Setiap event memakai ID notifikasi sebagai id, jadi klien yang reconnect dengan
+// header Last-Event-ID (atau ?last_event_id= untuk polyfill) menerima notifikasi yang terlewat.
+func StreamNotifications(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	lastEventParam := c.GetHeader("Last-Event-ID")
+	if lastEventParam == "" {
+		lastEventParam = c.Query("last_event_id")
+	}
+	var lastID uint
+	if lastEventParam != "" {
+		parsed, err := strconv.ParseUint(lastEventParam, 10, 32)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Last-Event-ID"})
+			return
+		}
+		lastID = uint(parsed)
+	}
+
+	realtime.EnsureListening(config.ListenDSN())
+
+	// Subscribe sebelum membaca backlog supaya tidak ada notifikasi yang jatuh di antaranya
+	events, cancel := realtime.Default.Subscribe(userID)
+	defer cancel()
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	w := c.Writer
+	fmt.Fprintf(w, "retry: 5000\n\n")
+
+	stream := &notificationStream{w: w, userID: userID, lastID: lastID, sent: map[uint]time.Time{}}
+	connectedAt := time.Now()
+	if lastID > 0 {
+		stream.catchUp(connectedAt.Add(-streamReplayGrace))
+	}
+	w.Flush()
+
+	ticker := time.NewTicker(streamKeepAlive)
+	defer ticker.Stop()
+
+	ctx := c.Request.Context()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			stream.prune()
+			fmt.Fprintf(w, ": keepalive\n\n")
+			w.Flush()
+		case id, open := <-events:
+			if !open {
+				return // Tertinggal terlalu jauh; klien reconnect dengan Last-Event-ID
+			}
+			if id == realtime.ResyncID {
+				// Listener sempat terputus; NOTIFY selama itu hilang, baca ulang dari database
+				since := time.Now().Add(-streamReplayGrace)
+				if stream.lastID == 0 && connectedAt.After(since) {
+					since = connectedAt
+				}
+				stream.catchUp(since)
+				w.Flush()
+				continue
+			}
+			if _, done := stream.sent[id]; done {
+				continue // sudah terkirim lewat backlog
+			}
+			var n models.Notification
+			if err := config.DB.Preload("Post").Where("id = ? AND user_id = ?", id, userID).First(&n).Error; err != nil {
+				continue
+			}
+			stream.write(n)
+			w.Flush()
+		}
+	}
+}
+
+// notificationStream mencatat notifikasi yang sudah dikirim pada satu koneksi SSE
+type notificationStream struct {
+	w      io.Writer
+	userID uint
+	lastID uint               // ID terbesar yang sudah dikirim
+	sent   map[uint]time.Time // ID yang sudah dikirim -> waktu dibuat, untuk menyaring duplikat
+}
+
+// catchUp mengirim notifikasi dengan ID di atas lastID, ditambah notifikasi yang dibuat sejak
+// since (bisa ber-ID lebih kecil karena commit belakangan), per halaman sampai habis
+func (s *notificationStream) catchUp(since time.Time) {
+	var cursor uint
+	for {
+		query := config.DB.Preload("Post").Where("user_id = ? AND id > ?", s.userID, cursor)
+		if s.lastID > 0 {
+			query = query.Where("(id > ? OR created_at > ?)", s.lastID, since)
+		} else {
+			query = query.Where("created_at > ?", since)
+		}
+		var backlog []models.Notification
+		if err := query.Order("id ASC").Limit(streamBacklogSize).Find(&backlog).Error; err != nil {
+			return
+		}
+		for _, n := range backlog {
+			cursor = n.ID
+			if _, done := s.sent[n.ID]; !done {
+				s.write(n)
+			}
+		}
+		if len(backlog) < streamBacklogSize {
+			return
+		}
+	}
+}
+
+func (s *notificationStream) write(n models.Notification) {
+	writeNotificationEvent(s.w, n)
+	s.sent[n.ID] = n.CreatedAt
+	if n.ID > s.lastID {
+		s.lastID = n.ID
+	}
+}
+
+// prune melepas ID lama dari daftar terkirim; notifikasi itu tidak akan dibaca ulang lagi
+func (s *notificationStream) prune() {
+	cutoff := time.Now().Add(-2 * streamReplayGrace)
+	for id, created := range s.sent {
+		if created.Before(cutoff) {
+			delete(s.sent, id)
+		}
+	}
+}
+
+func writeNotificationEvent(w io.Writer, n models.Notification) {
+	data, err := json.Marshal(notificationResponse(n))
+	if err != nil {
+		return
+	}
+	fmt.Fprintf(w, "id: %d\nevent: notification\ndata: %s\n\n", n.ID, data)
+}
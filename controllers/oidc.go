@@ -0,0 +1,369 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"filoti-backend/authtoken"
+	"filoti-backend/config"
+	"filoti-backend/models"
+	"filoti-backend/oidc"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+)
+
+const (
+	oidcFlowKey = "oidc_flow" // Key session untuk state login SSO yang sedang berjalan
+	oidcFlowTTL = 10 * time.Minute
+)
+
+// oidcFlow disimpan di session antara /auth/oidc/login dan /auth/oidc/callback
+type oidcFlow struct {
+	Provider  string `json:"provider"`
+	State     string `json:"state"`
+	Nonce     string `json:"nonce"`
+	Verifier  string `json:"verifier"`
+	ReturnTo  string `json:"return_to"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
+// oidcError adalah kegagalan login SSO; code dikirim ke frontend lewat ?error=
+type oidcError struct {
+	code string
+	err  error
+}
+
+func (e *oidcError) Error() string {
+	if e.err != nil {
+		return e.code + ": " + e.err.Error()
+	}
+	return e.code
+}
+
+var usernameInvalidChars = regexp.MustCompile(`[^a-z0-9._-]+`)
+
+// GetOIDCProviders handler: GET /auth/oidc/providers — daftar provider SSO untuk tombol login
+func GetOIDCProviders(c *gin.Context) {
+	names := make([]string, 0, len(config.OIDCProviders))
+	for name := range config.OIDCProviders {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	result := []gin.H{}
+	for _, name := range names {
+		p := config.OIDCProviders[name]
+		result = append(result, gin.H{
+			"name":         name,
+			"display_name": p.Config.DisplayName,
+			"login_url":    "/auth/oidc/login?provider=" + url.QueryEscape(name),
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// OIDCLogin handler: GET /auth/oidc/login?provider=campus&return_to=/posts — mengarahkan browser
+// ke halaman login provider (authorization code + PKCE)
+func OIDCLogin(c *gin.Context) {
+	name := c.Query("provider")
+	provider, ok := config.OIDCProviders[name]
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Unknown SSO provider"})
+		return
+	}
+	if !allowRequest(c, "oidc_login", loginRateRule) {
+		return
+	}
+
+	flow := oidcFlow{
+		Provider:  name,
+		ReturnTo:  safeReturnTo(c.Query("return_to")),
+		ExpiresAt: time.Now().Add(oidcFlowTTL).Unix(),
+	}
+	var err error
+	for _, v := range []*string{&flow.State, &flow.Nonce, &flow.Verifier} {
+		if *v, err = oidc.RandomString(); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start SSO login: " + err.Error()})
+			return
+		}
+	}
+	authURL, err := provider.AuthCodeURL(c.Request.Context(), flow.State, flow.Nonce, flow.Verifier)
+	if err != nil {
+		log.Printf("OIDCLogin: provider %s unavailable - %v", name, err)
+		c.JSON(http.StatusBadGateway, gin.H{"error": "SSO provider is unavailable"})
+		return
+	}
+
+	encoded, _ := json.Marshal(flow)
+	session := sessions.Default(c)
+	session.Set(oidcFlowKey, string(encoded))
+	if err := session.Save(); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save session"})
+		return
+	}
+	c.Redirect(http.StatusFound, authURL)
+}
+
+// OIDCCallback handler: GET /auth/oidc/callback — menukar code, memverifikasi ID token,
+// lalu menautkan atau membuat user berdasarkan claim sub dan membuat session
+func OIDCCallback(c *gin.Context) {
+	session := sessions.Default(c)
+	var flow oidcFlow
+	raw, _ := session.Get(oidcFlowKey).(string)
+	session.Delete(oidcFlowKey) // state hanya boleh dipakai sekali
+	if err := session.Save(); err != nil {
+		log.Printf("OIDCCallback: failed to clear flow state - %v", err)
+	}
+
+	if raw == "" || json.Unmarshal([]byte(raw), &flow) != nil || time.Now().Unix() > flow.ExpiresAt {
+		oidcFail(c, flow.Provider, &oidcError{code: "sso_expired"})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.Query("state")), []byte(flow.State)) != 1 {
+		oidcFail(c, flow.Provider, &oidcError{code: "sso_state_mismatch"})
+		return
+	}
+	if e := c.Query("error"); e != "" {
+		oidcFail(c, flow.Provider, &oidcError{code: "sso_denied", err: errors.New(e + " " + c.Query("error_description"))})
+		return
+	}
+	provider, ok := config.OIDCProviders[flow.Provider]
+	if !ok || c.Query("code") == "" {
+		oidcFail(c, flow.Provider, &oidcError{code: "sso_failed"})
+		return
+	}
+
+	claims, err := provider.Exchange(c.Request.Context(), c.Query("code"), flow.Verifier, flow.Nonce)
+	if err != nil {
+		oidcFail(c, flow.Provider, &oidcError{code: "sso_failed", err: err})
+		return
+	}
+
+	var user models.User
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if user, err = resolveOIDCUser(tx, c, provider, claims); err != nil {
+			return err
+		}
+		return syncOIDCRole(tx, c, provider, claims, &user)
+	})
+	if err != nil {
+		oidcFail(c, flow.Provider, err)
+		return
+	}
+	if user.IsSuspended() {
+		recordAuditBestEffort(c, auditEntry{Action: "auth.login_blocked", EntityType: "user", EntityID: user.ID, ActorID: user.ID})
+		oidcFail(c, flow.Provider, &oidcError{code: "account_suspended"})
+		return
+	}
+
+	// 2FA lokal tetap berlaku; frontend menyelesaikannya dengan POST /login/2fa
+	if user.TwoFactorEnabled() {
+		var challenge string
+		err := config.DB.Transaction(func(tx *gorm.DB) error {
+			var err error
+			challenge, err = createUserToken(tx, user.ID, models.TokenLoginChallenge, "", loginChallengeTTL)
+			return err
+		})
+		if err != nil {
+			oidcFail(c, flow.Provider, &oidcError{code: "sso_failed", err: err})
+			return
+		}
+		// Token di fragment agar tidak tercatat di log server mana pun
+		c.Redirect(http.StatusFound, appURL()+"/login/2fa#mfa_token="+url.QueryEscape(challenge))
+		return
+	}
+
+	if err := saveLoginSession(c, user); err != nil {
+		oidcFail(c, flow.Provider, &oidcError{code: "sso_failed", err: err})
+		return
+	}
+	recordAuditBestEffort(c, auditEntry{Action: "auth.login", EntityType: "user", EntityID: user.ID, ActorID: user.ID,
+		After: gin.H{"method": "oidc", "provider": flow.Provider}})
+	c.Redirect(http.StatusFound, appURL()+flow.ReturnTo)
+}
+
+// oidcFail mencatat kegagalan lalu mengarahkan browser kembali ke halaman login frontend
+func oidcFail(c *gin.Context, provider string, err error) {
+	code := "sso_failed"
+	var oe *oidcError
+	if errors.As(err, &oe) {
+		code = oe.code
+	}
+	log.Printf("OIDCCallback: login via %q failed - %v", provider, err)
+	recordAuditBestEffort(c, auditEntry{Action: "auth.login_failed", EntityType: "oidc", EntityID: provider,
+		After: gin.H{"reason": code}})
+	c.Redirect(http.StatusFound, appURL()+"/login?error="+url.QueryEscape(code))
+}
+
+// resolveOIDCUser mencari user yang tertaut ke sub, menautkan lewat email terverifikasi
+// (jika diizinkan), atau membuat user baru
+func resolveOIDCUser(tx *gorm.DB, c *gin.Context, provider *config.OIDCProvider, claims *oidc.Claims) (models.User, error) {
+	var user models.User
+	name := provider.Config.Name
+	email, emailOK := normalizeEmail(claims.Email)
+	emailOK = emailOK && claims.EmailVerified
+
+	var identity models.UserIdentity
+	err := tx.Where("provider = ? AND subject = ?", name, claims.Subject).First(&identity).Error
+	if err == nil {
+		if err := tx.First(&user, identity.UserID).Error; err != nil {
+			return user, err
+		}
+		return user, tx.Model(&identity).Updates(map[string]interface{}{
+			"last_login_at": time.Now(),
+			"email":         claims.Email,
+		}).Error
+	}
+	if err != gorm.ErrRecordNotFound {
+		return user, err
+	}
+
+	action := "auth.oidc_link"
+	err = gorm.ErrRecordNotFound
+	if provider.LinkByEmail && emailOK {
+		err = tx.Where("email = ? AND email_verified_at IS NOT NULL", email).First(&user).Error
+	}
+	if err == gorm.ErrRecordNotFound {
+		if !provider.AllowSignup {
+			return user, &oidcError{code: "sso_not_registered"}
+		}
+		action = "auth.oidc_signup"
+		if user, err = provisionOIDCUser(tx, claims, email, emailOK); err != nil {
+			return user, err
+		}
+	} else if err != nil {
+		return user, err
+	}
+
+	identity = models.UserIdentity{
+		UserID:      user.ID,
+		Provider:    name,
+		Subject:     claims.Subject,
+		Email:       claims.Email,
+		LastLoginAt: time.Now(),
+	}
+	if err := tx.Create(&identity).Error; err != nil {
+		return user, err
+	}
+	return user, recordAudit(tx, c, auditEntry{Action: action, EntityType: "user", EntityID: user.ID, ActorID: user.ID,
+		After: identity})
+}
+
+// provisionOIDCUser membuat user baru dari claim. Password diisi acak; user tetap bisa
+// memakai lupa password jika email dari provider sudah terverifikasi.
+func provisionOIDCUser(tx *gorm.DB, claims *oidc.Claims, email string, emailOK bool) (models.User, error) {
+	var user models.User
+	username, err := uniqueUsername(tx, usernameCandidate(claims))
+	if err != nil {
+		return user, err
+	}
+	password, err := authtoken.RandomToken(32)
+	if err != nil {
+		return user, err
+	}
+	hashed, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return user, err
+	}
+	user = models.User{Username: username, Password: string(hashed), Role: models.RoleStudent}
+	if emailOK {
+		var taken int64
+		if err := tx.Model(&models.User{}).Where("email = ?", email).Count(&taken).Error; err != nil {
+			return user, err
+		}
+		if taken == 0 {
+			now := time.Now()
+			user.Email = &email
+			user.EmailVerifiedAt = &now
+		}
+	}
+	return user, tx.Create(&user).Error
+}
+
+// usernameCandidate memilih username dari preferred_username, bagian lokal email, atau sub
+func usernameCandidate(claims *oidc.Claims) string {
+	base := claims.PreferredUsername
+	if base == "" {
+		base, _, _ = strings.Cut(claims.Email, "@")
+	}
+	base = strings.Trim(usernameInvalidChars.ReplaceAllString(strings.ToLower(base), ""), ".-_")
+	if len(base) > 40 {
+		base = base[:40]
+	}
+	if base == "" || base == models.GuestUsername { // Dipakai GuestLogin
+		base = "sso-user"
+	}
+	return base
+}
+
+// uniqueUsername menambahkan akhiran angka sampai username belum dipakai
+func uniqueUsername(tx *gorm.DB, base string) (string, error) {
+	candidate := base
+	for i := 2; i < 100; i++ {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("username = ?", candidate).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return candidate, nil
+		}
+		candidate = fmt.Sprintf("%s-%d", base, i)
+	}
+	suffix, err := randomString("abcdefghjkmnpqrstuvwxyz23456789", 6)
+	if err != nil {
+		return "", err
+	}
+	return base + "-" + suffix, nil
+}
+
+// syncOIDCRole menyamakan role user dengan grup dari provider jika ROLE_MAP dikonfigurasi.
+// User tanpa grup yang cocok kembali menjadi student.
+func syncOIDCRole(tx *gorm.DB, c *gin.Context, provider *config.OIDCProvider, claims *oidc.Claims, user *models.User) error {
+	if len(provider.RoleMap) == 0 {
+		return nil
+	}
+	role := models.RoleStudent
+	for _, group := range claims.Groups(provider.GroupsClaim) {
+		if mapped, ok := provider.RoleMap[group]; ok && mapped.Rank() > role.Rank() {
+			role = mapped
+		}
+	}
+	if role == user.Role {
+		return nil
+	}
+	if err := ensureNotLastSuperAdmin(tx, *user, "demote"); err != nil {
+		log.Printf("syncOIDCRole: keeping role of user %d - %v", user.ID, err)
+		return nil
+	}
+	before := *user
+	if err := tx.Model(user).Update("role", role).Error; err != nil {
+		return err
+	}
+	user.Role = role
+	if err := revokeUserAccess(tx, user.ID, ""); err != nil {
+		return err
+	}
+	return recordAudit(tx, c, auditEntry{Action: "user.role_sync", EntityType: "user", EntityID: user.ID, ActorID: user.ID,
+		Before: gin.H{"role": before.Role}, After: gin.H{"role": role, "provider": provider.Config.Name}})
+}
+
+// safeReturnTo hanya menerima path relatif di frontend, agar login tidak bisa dipakai sebagai open redirect
+func safeReturnTo(raw string) string {
+	if !strings.HasPrefix(raw, "/") || strings.HasPrefix(raw, "//") || strings.ContainsAny(raw, "\\\r\n") {
+		return "/"
+	}
+	return raw
+}
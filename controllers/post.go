@@ -1,7 +1,6 @@
 package controllers
 
 import (
-	"fmt" // <--- PASTIKAN INI DIIMPOR untuk fmt.Sprintf
 	"net/http"
 
 	"filoti-backend/config"
@@ -43,6 +42,10 @@ func CreatePost(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateImageURL(config.DB, input.ImageURL); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image_url: " + err.Error()})
+		return
+	}
 
 	post := models.Post{
 		Title:      input.Title,
@@ -50,6 +53,7 @@ func CreatePost(c *gin.Context) {
 		Ruangan:    input.Ruangan,
 		ImageURL:   input.ImageURL,
 		ItemType:   input.ItemType,
+		AuthorID:   &currentUserID,
 	}
 
 	tx := config.DB.Begin()
@@ -61,67 +65,217 @@ func CreatePost(c *gin.Context) {
 
 	status := models.Status{
 		PostID:    post.ID,
-		Status:    1,
 		UpdatedBy: currentUserID, // Gunakan ID admin yang sedang login
 	}
+	status.SetState(models.StateReported)
 	if err := tx.Create(&status).Error; err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create status: " + err.Error()})
 		return
 	}
+	if err := recordStatusHistory(tx, post.ID, "", models.StateReported, currentUserID, "Post dibuat"); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record status history: " + err.Error()})
+		return
+	}
 
-	// PERBAIKAN DI SINI: Gunakan fmt.Sprintf untuk memformat ID menjadi string
-	message := fmt.Sprintf("Post baru dibuat oleh admin (ID: %d): %s", currentUserID, post.Title) // <--- PERBAIKAN PENTING
-
-	notification := models.Notification{
-		PostID:  post.ID,
-		Message: message,
-		IsRead:  false,
+	event := notificationEvent{
+		Type:   models.NotificationPostCreated,
+		PostID: post.ID,
+		Payload: map[string]interface{}{
+			"post_id":    post.ID,
+			"title":      post.Title,
+			"ruangan":    post.Ruangan,
+			"item_type":  post.ItemType,
+			"actor_id":   currentUserID,
+			"actor_name": usernameOf(tx, currentUserID),
+		},
 	}
-	if err := tx.Create(&notification).Error; err != nil {
+	if err := notifyAdmins(tx, event); err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification: " + err.Error()})
 		return
 	}
+	post.Status = status
+	if err := recordAudit(tx, c, auditEntry{Action: "post.create", EntityType: "post", EntityID: post.ID, After: post}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record audit log: " + err.Error()})
+		return
+	}
 	tx.Commit()
 
-	if err := config.DB.Preload("Status").First(&post, post.ID).Error; err != nil {
+	suggestMatchesForPost(config.DB, post)
+	notifySubscribers(config.DB, post)
+
+	if err := config.DB.Preload("Status").Preload("Author").First(&post, post.ID).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post after creation: " + err.Error()})
 		return
 	}
+	attachPostImageVariants(config.DB, &post)
+	c.Header("ETag", postETag(post))
 	c.JSON(http.StatusCreated, gin.H{"message": "Post created successfully", "post": post})
 }
 
-// GetPosts handler: mengambil semua post
+// GetPosts handler: mengambil post dengan cursor pagination, filter dan sorting.
+//
+// Query yang didukung:
+//   - limit: jumlah post per halaman (default 20, maksimum 100)
+//   - cursor: nilai next_cursor dari respons sebelumnya
+//   - ruangan, itemType: filter exact match
+//   - status: "active" atau "done"
+//   - state: salah satu models.PostState (reported, in_storage, ...)
+//   - from, to: rentang created_at ("2006-01-02" atau RFC3339)
+//   - sort: "newest" (default) atau "oldest"
 func GetPosts(c *gin.Context) {
+	limit, err := parseLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := config.DB.Model(&models.Post{})
+
+	if ruangan := c.Query("ruangan"); ruangan != "" {
+		query = query.Where("posts.ruangan = ?", ruangan)
+	}
+	itemType := c.Query("itemType")
+	if itemType == "" {
+		itemType = c.Query("item_type")
+	}
+	if itemType != "" {
+		query = query.Where("posts.item_type = ?", itemType)
+	}
+	if statusParam := c.Query("status"); statusParam != "" {
+		var statusValue int
+		switch statusParam {
+		case "active", "1":
+			statusValue = 1
+		case "done", "0":
+			statusValue = 0
+		default:
+			c.JSON(http.StatusBadRequest, gin.H{"error": "status must be 'active' or 'done'"})
+			return
+		}
+		query = query.Joins("JOIN statuses ON statuses.post_id = posts.id AND statuses.status = ?", statusValue)
+	}
+	if stateParam := c.Query("state"); stateParam != "" {
+		state := models.PostState(stateParam)
+		if !state.Valid() {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown state: " + stateParam})
+			return
+		}
+		query = query.Where("EXISTS (SELECT 1 FROM statuses s WHERE s.post_id = posts.id AND s.state = ?)", state)
+	}
+	if from := c.Query("from"); from != "" {
+		fromTime, err := parseDateParam(from, false)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'from' date"})
+			return
+		}
+		query = query.Where("posts.created_at >= ?", fromTime)
+	}
+	if to := c.Query("to"); to != "" {
+		toTime, err := parseDateParam(to, true)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid 'to' date"})
+			return
+		}
+		query = query.Where("posts.created_at <= ?", toTime)
+	}
+
+	ascending := false
+	switch c.DefaultQuery("sort", "newest") {
+	case "newest":
+	case "oldest":
+		ascending = true
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "sort must be 'newest' or 'oldest'"})
+		return
+	}
+
+	// Total dihitung sebelum cursor diterapkan agar tetap konsisten di setiap halaman
+	var total int64
+	if err := query.Session(&gorm.Session{}).Count(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to count posts: " + err.Error()})
+		return
+	}
+
+	if cursorParam := c.Query("cursor"); cursorParam != "" {
+		cursor, err := decodeCursor(cursorParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		if ascending {
+			query = query.Where("(posts.created_at, posts.id) > (?, ?)", cursor.CreatedAt, cursor.ID)
+		} else {
+			query = query.Where("(posts.created_at, posts.id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+		}
+	}
+
+	order := "posts.created_at DESC, posts.id DESC"
+	if ascending {
+		order = "posts.created_at ASC, posts.id ASC"
+	}
+
+	// Ambil satu baris ekstra untuk mengetahui apakah masih ada halaman berikutnya
 	var posts []models.Post
-	if err := config.DB.Preload("Status").Find(&posts).Error; err != nil {
+	if err := query.Preload("Status").Preload("Author").Order(order).Limit(limit + 1).Find(&posts).Error; err != nil {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve posts: " + err.Error()})
 		return
 	}
 
+	hasMore := len(posts) > limit
+	if hasMore {
+		posts = posts[:limit]
+	}
+	attachImageVariants(config.DB, posts)
+
 	var postsToReturn []gin.H
 	for _, p := range posts {
-		username := "Administrator" // Placeholder
-
-		postsToReturn = append(postsToReturn, gin.H{
-			"id":         p.ID,
-			"username":   username,
-			"image_url":  p.ImageURL,
-			"title":      p.Title,
-			"ruangan":    p.Ruangan,
-			"keterangan": p.Keterangan,
-			"item_type":  p.ItemType,
-			"created_at": p.CreatedAt,
-			"status":     p.Status.Status,
-		})
+		postsToReturn = append(postsToReturn, postListItem(p))
 	}
 
 	if postsToReturn == nil {
 		postsToReturn = []gin.H{}
 	}
 
-	c.JSON(http.StatusOK, postsToReturn)
+	var nextCursor interface{}
+	if hasMore {
+		last := posts[len(posts)-1]
+		nextCursor = encodeCursor(last.CreatedAt, last.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts":       postsToReturn,
+		"next_cursor": nextCursor,
+		"has_more":    hasMore,
+		"total":       total,
+		"limit":       limit,
+	})
+}
+
+// postListItem memformat post untuk respons list (GetPosts, SearchPosts)
+func postListItem(p models.Post) gin.H {
+	var username interface{} // null jika author tidak diketahui (post lama)
+	if p.Author != nil {
+		username = p.Author.Username
+	}
+
+	return gin.H{
+		"id":             p.ID,
+		"author_id":      p.AuthorID,
+		"username":       username,
+		"image_url":      p.ImageURL,
+		"image_variants": p.ImageVariants,
+		"title":          p.Title,
+		"ruangan":        p.Ruangan,
+		"keterangan":     p.Keterangan,
+		"item_type":      p.ItemType,
+		"created_at":     p.CreatedAt,
+		"status":         p.Status.Status,
+		"etag":           postETag(p), // Untuk header If-Match saat mengedit dari list
+	}
 }
 
 // --- TAMBAHKAN FUNGSI BARU INI UNTUK GET /posts/:id ---
@@ -132,8 +286,7 @@ func GetPostByID(c *gin.Context) {
 	var post models.Post
 
 	// Cari post di database berdasarkan ID
-	// Preload Status dan mungkin Admin (jika AdminID ditambahkan ke Post)
-	if err := config.DB.Preload("Status").First(&post, id).Error; err != nil {
+	if err := config.DB.Preload("Status").Preload("Author").First(&post, id).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 			return
@@ -141,24 +294,14 @@ func GetPostByID(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post: " + err.Error()})
 		return
 	}
+	attachPostImageVariants(config.DB, &post)
 
-	// Anda mungkin ingin menambahkan AdminID ke model Post
-	// dan preload Admin di sini jika ingin menampilkan username admin yang membuat post ini
-	// username := "Administrator" // Placeholder
-
-	// Format respons agar sesuai dengan yang diharapkan frontend
-	// postToReturn := gin.H{
-	// 	"id":         post.ID,
-	// 	"username":   username, // Placeholder atau dari relasi Admin
-	// 	"image_url":  post.ImageURL,
-	// 	"title":      post.Title,
-	// 	"ruangan":    post.Ruangan,
-	// 	"keterangan": post.Keterangan,
-	// 	"item_type":  post.ItemType,
-	// 	"created_at": post.CreatedAt,
-	// 	"status":     post.Status.Status, // Mengirim status int
-	// }
-
+	etag := postETag(post)
+	c.Header("ETag", etag)
+	if match := c.GetHeader("If-None-Match"); match != "" && etagMatches(match, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
 	c.JSON(http.StatusOK, post)
 }
 
@@ -0,0 +1,107 @@
+package controllers
+
+import (
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"filoti-backend/config"
+	"filoti-backend/ratelimit"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Batas laju endpoint autentikasi per IP
+var (
+	loginRateRule   = ratelimit.Rule{Limit: 20, Window: 15 * time.Minute} // /login dan /auth/token
+	signupRateRule  = ratelimit.Rule{Limit: 5, Window: time.Hour}
+	guestRateRule   = ratelimit.Rule{Limit: 10, Window: time.Hour}
+	forgotRateRule  = ratelimit.Rule{Limit: 5, Window: time.Hour} // Setiap request bisa mengirim email
+	refreshRateRule = ratelimit.Rule{Limit: 60, Window: 15 * time.Minute}
+
+	// Lockout sementara setelah password salah berulang kali, per username dan per IP
+	usernameBackoff = ratelimit.Backoff{Threshold: 5, Window: 24 * time.Hour, Base: time.Minute, Max: time.Hour}
+	ipBackoff       = ratelimit.Backoff{Threshold: 20, Window: 24 * time.Hour, Base: time.Minute, Max: time.Hour}
+)
+
+// writeTooManyRequests membalas 429 dengan header Retry-After (detik, dibulatkan ke atas)
+func writeTooManyRequests(c *gin.Context, retryAfter time.Duration, message string) {
+	seconds := int(math.Ceil(retryAfter.Seconds()))
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{"error": message, "retry_after": seconds})
+}
+
+// allowRequest menerapkan rule per IP untuk endpoint name; false berarti 429 sudah dikirim.
+// Jika penyimpanan counter bermasalah request tetap diizinkan (fail open) dan error di-log.
+func allowRequest(c *gin.Context, name string, rule ratelimit.Rule) bool {
+	if config.Limiter == nil {
+		return true
+	}
+	retryAfter, err := config.Limiter.Allow(c.Request.Context(), name+":ip:"+c.ClientIP(), rule)
+	if err != nil {
+		log.Printf("allowRequest: rate limiter error for %s - %v", name, err)
+		return true
+	}
+	if retryAfter > 0 {
+		writeTooManyRequests(c, retryAfter, "Too many requests, please try again later")
+		return false
+	}
+	return true
+}
+
+// allowLoginAttempt menolak percobaan login jika username atau IP sedang dikunci
+func allowLoginAttempt(c *gin.Context, username string) bool {
+	if !allowRequest(c, "login", loginRateRule) {
+		return false
+	}
+	if config.Limiter == nil {
+		return true
+	}
+	ctx := c.Request.Context()
+	var retryAfter time.Duration
+	for _, key := range []string{"login:user:" + username, "login:ip:" + c.ClientIP()} {
+		d, err := config.Limiter.Locked(ctx, key)
+		if err != nil {
+			log.Printf("allowLoginAttempt: rate limiter error - %v", err)
+			continue
+		}
+		if d > retryAfter {
+			retryAfter = d
+		}
+	}
+	if retryAfter > 0 {
+		writeTooManyRequests(c, retryAfter, "Too many failed login attempts, please try again later")
+		return false
+	}
+	return true
+}
+
+// recordLoginFailure mencatat password salah (atau username tidak dikenal) untuk backoff
+func recordLoginFailure(c *gin.Context, username string) {
+	if config.Limiter == nil {
+		return
+	}
+	ctx := c.Request.Context()
+	locked, err := config.Limiter.Fail(ctx, "login:user:"+username, usernameBackoff)
+	if err != nil {
+		log.Printf("recordLoginFailure: rate limiter error - %v", err)
+	} else if locked > 0 {
+		recordAuditBestEffort(c, auditEntry{Action: "auth.lockout", EntityType: "user", EntityID: username,
+			After: gin.H{"locked_for_seconds": int(locked.Seconds())}})
+	}
+	if _, err := config.Limiter.Fail(ctx, "login:ip:"+c.ClientIP(), ipBackoff); err != nil {
+		log.Printf("recordLoginFailure: rate limiter error - %v", err)
+	}
+}
+
+// recordLoginSuccess menghapus riwayat kegagalan username setelah login berhasil
+func recordLoginSuccess(c *gin.Context, username string) {
+	if config.Limiter == nil {
+		return
+	}
+	if err := config.Limiter.Succeed(c.Request.Context(), "login:user:"+username); err != nil {
+		log.Printf("recordLoginSuccess: rate limiter error - %v", err)
+	}
+}
@@ -0,0 +1,154 @@
+package controllers
+
+import (
+	"net/http"
+	"strconv"
+
+	"filoti-backend/config"
+	"filoti-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GetRoles handler: GET /admin/roles — daftar role beserta izinnya
+func GetRoles(c *gin.Context) {
+	roles := make([]gin.H, 0, len(models.Roles))
+	for _, r := range models.Roles {
+		roles = append(roles, gin.H{"role": r, "permissions": r.Permissions()})
+	}
+	c.JSON(http.StatusOK, roles)
+}
+
+// Input untuk mengganti role user
+type AssignRoleInput struct {
+	Role models.Role `json:"role" binding:"required"`
+}
+
+// AssignRole handler: PUT /admin/users/:id/role — mengganti role user (izin roles:assign)
+func AssignRole(c *gin.Context) {
+	actorID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	targetID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid User ID"})
+		return
+	}
+
+	var input AssignRoleInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !input.Role.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown role: " + string(input.Role)})
+		return
+	}
+
+	var user models.User
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var before models.Role
+		user, before, err = setUserRole(tx, uint(targetID), actorID, input.Role)
+		if err != nil || before == user.Role {
+			return err
+		}
+		return recordAudit(tx, c, auditEntry{
+			Action:     "user.role_change",
+			EntityType: "user",
+			EntityID:   user.ID,
+			Before:     gin.H{"role": before},
+			After:      gin.H{"role": user.Role},
+		})
+	})
+	if err != nil {
+		writeRoleError(c, err, "Failed to update role")
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Role updated successfully", "user": user})
+}
+
+// roleError dikembalikan untuk perubahan akun/role yang ditolak (status HTTP + pesan)
+type roleError struct {
+	status  int
+	message string
+}
+
+func (e *roleError) Error() string { return e.message }
+
+// setUserRole mengganti role user di dalam transaksi tx dan mengembalikan role sebelumnya.
+// Super admin terakhir tidak bisa diturunkan, dan user tidak bisa mengubah role-nya sendiri
+// agar tidak terkunci dari panel admin.
+func setUserRole(tx *gorm.DB, targetID, actorID uint, role models.Role) (models.User, models.Role, error) {
+	user, err := lockManagedUser(tx, targetID, actorID)
+	if err != nil {
+		return user, "", err
+	}
+	before := user.Role
+	if before == role {
+		return user, before, nil
+	}
+	if err := ensureNotLastSuperAdmin(tx, user, "demote"); err != nil {
+		return user, before, err
+	}
+	if err := tx.Model(&user).Update("role", role).Error; err != nil {
+		return user, before, err
+	}
+	// User harus login ulang agar perubahan role terlihat di semua perangkatnya
+	if err := revokeUserAccess(tx, user.ID, ""); err != nil {
+		return user, before, err
+	}
+	return user, before, nil
+}
+
+// lockManagedUser membaca user target dengan FOR UPDATE; admin tidak boleh mengelola akunnya sendiri
+func lockManagedUser(tx *gorm.DB, targetID, actorID uint) (models.User, error) {
+	var user models.User
+	if targetID == actorID {
+		return user, &roleError{http.StatusConflict, "You cannot change your own account here"}
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, targetID).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return user, &roleError{http.StatusNotFound, "User not found"}
+		}
+		return user, err
+	}
+	return user, nil
+}
+
+// superAdminLockKey adalah kunci pg_advisory_xact_lock untuk semua aksi yang bisa mengurangi super admin
+const superAdminLockKey = 0x66696c6f7469 // "filoti"
+
+// ensureNotLastSuperAdmin menolak aksi (demote/suspend/delete) terhadap super admin aktif terakhir.
+// Advisory lock membuat pemeriksaan ini berurutan: tanpa itu dua transaksi yang masing-masing
+// menurunkan super admin berbeda bisa sama-sama melihat 2 super admin dan keduanya lolos.
+func ensureNotLastSuperAdmin(tx *gorm.DB, user models.User, action string) error {
+	if user.Role != models.RoleSuperAdmin {
+		return nil
+	}
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", superAdminLockKey).Error; err != nil {
+		return err
+	}
+	var superAdmins int64
+	if err := tx.Model(&models.User{}).
+		Where("role = ? AND suspended_at IS NULL", models.RoleSuperAdmin).
+		Count(&superAdmins).Error; err != nil {
+		return err
+	}
+	if superAdmins <= 1 {
+		return &roleError{http.StatusConflict, "Cannot " + action + " the last super admin"}
+	}
+	return nil
+}
+
+// writeRoleError menulis roleError apa adanya, error lain sebagai 500 dengan awalan failure
+func writeRoleError(c *gin.Context, err error, failure string) {
+	if re, ok := err.(*roleError); ok {
+		c.JSON(re.status, gin.H{"error": re.message})
+		return
+	}
+	c.JSON(http.StatusInternalServerError, gin.H{"error": failure + ": " + err.Error()})
+}
@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"database/sql"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"filoti-backend/config"
+	"filoti-backend/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Query tsquery gabungan Indonesia + Inggris, sama dengan konfigurasi kolom posts.search_vector
+const searchTSQuery = `(websearch_to_tsquery('indonesian'::regconfig, @q) || websearch_to_tsquery('english'::regconfig, @q))`
+
+const headlineOptions = `StartSel=<mark>, StopSel=</mark>, MaxWords=35, MinWords=15, MaxFragments=2, FragmentDelimiter=" … "`
+
+type postSearchHit struct {
+	ID                uint
+	Rank              float64
+	TitleHighlight    string
+	KeteranganSnippet string
+	RuanganHighlight  string
+}
+
+// SearchPosts handler: GET /posts/search?q= — full-text search dengan ranking relevansi
+// dan potongan teks yang di-highlight. Mendukung ?limit= dan ?offset=.
+func SearchPosts(c *gin.Context) {
+	q := strings.TrimSpace(c.Query("q"))
+	if q == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Query parameter 'q' is required"})
+		return
+	}
+	limit, err := parseLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	offset := 0
+	if offsetStr := c.Query("offset"); offsetStr != "" {
+		offset, err = strconv.Atoi(offsetStr)
+		if err != nil || offset < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "offset must be a non-negative integer"})
+			return
+		}
+	}
+
+	var total int64
+	if err := config.DB.Raw(
+		`SELECT count(*) FROM posts WHERE deleted_at IS NULL AND search_vector @@ `+searchTSQuery,
+		sql.Named("q", q),
+	).Scan(&total).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search posts: " + err.Error()})
+		return
+	}
+
+	var hits []postSearchHit
+	if err := config.DB.Raw(`
+		WITH query AS (SELECT `+searchTSQuery+` AS tsq)
+		SELECT p.id,
+			ts_rank_cd(p.search_vector, query.tsq) AS rank,
+			ts_headline('indonesian', coalesce(p.title, ''), query.tsq, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS title_highlight,
+			ts_headline('indonesian', coalesce(p.keterangan, ''), query.tsq, @opts) AS keterangan_snippet,
+			ts_headline('simple', coalesce(p.ruangan, ''), query.tsq, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true') AS ruangan_highlight
+		FROM posts p, query
+		WHERE p.deleted_at IS NULL AND p.search_vector @@ query.tsq
+		ORDER BY rank DESC, p.created_at DESC, p.id DESC
+		LIMIT @limit OFFSET @offset`,
+		sql.Named("q", q),
+		sql.Named("opts", headlineOptions),
+		sql.Named("limit", limit),
+		sql.Named("offset", offset),
+	).Scan(&hits).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to search posts: " + err.Error()})
+		return
+	}
+
+	results := []gin.H{}
+	if len(hits) > 0 {
+		ids := make([]uint, len(hits))
+		for i, h := range hits {
+			ids[i] = h.ID
+		}
+		var posts []models.Post
+		if err := config.DB.Preload("Status").Preload("Author").Where("id IN ?", ids).Find(&posts).Error; err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve posts: " + err.Error()})
+			return
+		}
+		attachImageVariants(config.DB, posts)
+		byID := make(map[uint]models.Post, len(posts))
+		for _, p := range posts {
+			byID[p.ID] = p
+		}
+
+		// Pertahankan urutan ranking dari kueri pencarian
+		for _, h := range hits {
+			p, ok := byID[h.ID]
+			if !ok {
+				continue
+			}
+			item := postListItem(p)
+			item["rank"] = h.Rank
+			item["highlight"] = gin.H{
+				"title":      h.TitleHighlight,
+				"keterangan": h.KeteranganSnippet,
+				"ruangan":    h.RuanganHighlight,
+			}
+			results = append(results, item)
+		}
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts":  results,
+		"total":  total,
+		"limit":  limit,
+		"offset": offset,
+	})
+}
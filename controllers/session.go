@@ -0,0 +1,99 @@
+package controllers
+
+import (
+	"net/http"
+	"time"
+
+	"filoti-backend/config"
+	"filoti-backend/models"
+	"filoti-backend/sessionstore"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// GetMySessions handler: GET /me/sessions — perangkat (session browser) yang masih aktif
+func GetMySessions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var rows []models.UserSession
+	if err := config.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_seen_at DESC").Find(&rows).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve sessions: " + err.Error()})
+		return
+	}
+
+	current := currentSessionHash(c)
+	result := make([]gin.H, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, gin.H{
+			"id":           row.ID,
+			"ip":           row.IP,
+			"user_agent":   row.UserAgent,
+			"created_at":   row.CreatedAt,
+			"last_seen_at": row.LastSeenAt,
+			"expires_at":   row.ExpiresAt,
+			"current":      row.TokenHash == current,
+		})
+	}
+	c.JSON(http.StatusOK, result)
+}
+
+// DeleteMySession handler: DELETE /me/sessions/:id — logout dari satu perangkat
+func DeleteMySession(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	result := config.DB.Model(&models.UserSession{}).
+		Where("id = ? AND user_id = ? AND revoked_at IS NULL", c.Param("id"), userID).
+		Update("revoked_at", time.Now())
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke session: " + result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Session not found"})
+		return
+	}
+	recordAuditBestEffort(c, auditEntry{Action: "auth.session_revoke", EntityType: "session", EntityID: c.Param("id")})
+	c.JSON(http.StatusOK, gin.H{"message": "Session revoked"})
+}
+
+// LogoutUserEverywhere handler: POST /admin/users/:id/logout-all — me-revoke semua session dan refresh token user
+func LogoutUserEverywhere(c *gin.Context) {
+	manageUser(c, "user.logout_all", "Failed to log out user", func(tx *gorm.DB, user *models.User) error {
+		return revokeUserAccess(tx, user.ID, "")
+	})
+}
+
+// revokeUserAccess me-revoke semua session browser dan refresh token milik user,
+// kecuali session dengan hash keepSession (session yang sedang dipakai, boleh kosong).
+// Access token yang sudah terbit tetap berlaku sampai kedaluwarsa (ACCESS_TOKEN_TTL).
+func revokeUserAccess(tx *gorm.DB, userID uint, keepSession string) error {
+	now := time.Now()
+	if err := tx.Model(&models.RefreshToken{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Update("revoked_at", now).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL AND token_hash <> ?", userID, keepSession).
+		Update("revoked_at", now).Error
+}
+
+// currentSessionHash mengembalikan hash session cookie request ini, atau "" jika tidak ada
+func currentSessionHash(c *gin.Context) string {
+	id := sessions.Default(c).ID()
+	if id == "" {
+		return ""
+	}
+	return sessionstore.TokenHash(id)
+}
@@ -0,0 +1,168 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"filoti-backend/config"
+	"filoti-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// transitionError dikembalikan jika perpindahan state tidak diizinkan
+type transitionError struct {
+	From, To models.PostState
+}
+
+func (e *transitionError) Error() string {
+	return fmt.Sprintf("cannot change state from '%s' to '%s'", e.From, e.To)
+}
+
+// transitionPost memindahkan status post ke state baru (jika diizinkan) dan mencatat riwayatnya.
+// status harus sudah dibaca di dalam transaksi tx, sebaiknya dengan FOR UPDATE.
+func transitionPost(tx *gorm.DB, status *models.Status, to models.PostState, actorID uint, note string) error {
+	from := status.State
+	if !models.CanTransition(from, to) {
+		return &transitionError{From: from, To: to}
+	}
+
+	status.SetState(to)
+	status.Version++
+	status.UpdatedBy = actorID
+	status.UpdatedAt = time.Now()
+	if err := tx.Save(status).Error; err != nil {
+		return err
+	}
+	return recordStatusHistory(tx, status.PostID, from, to, actorID, note)
+}
+
+func recordStatusHistory(tx *gorm.DB, postID uint, from, to models.PostState, actorID uint, note string) error {
+	history := models.StatusHistory{
+		PostID:    postID,
+		FromState: from,
+		ToState:   to,
+		ActorID:   &actorID,
+		Note:      note,
+	}
+	return tx.Create(&history).Error
+}
+
+// lockStatus membaca status post dengan FOR UPDATE di dalam transaksi
+func lockStatus(tx *gorm.DB, postID uint) (models.Status, error) {
+	var status models.Status
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("post_id = ?", postID).First(&status).Error
+	return status, err
+}
+
+// Input untuk mengubah state post
+type TransitionInput struct {
+	State models.PostState `json:"state" binding:"required"`
+	Note  string           `json:"note"`
+}
+
+// TransitionPost handler: POST /posts/:id/transition — admin memindahkan post ke state lain
+func TransitionPost(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input TransitionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if !input.State.Valid() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Unknown state: " + string(input.State)})
+		return
+	}
+
+	postID, ok := parsePostID(c)
+	if !ok {
+		return
+	}
+
+	tx := config.DB.Begin()
+	post, err := lockPostForWrite(tx, postID)
+	if err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post: " + err.Error()})
+		return
+	}
+	if !checkIfMatch(c, postETag(post)) {
+		tx.Rollback()
+		return
+	}
+	status := post.Status
+	from := status.State
+	if err := transitionPost(tx, &status, input.State, adminID, input.Note); err != nil {
+		tx.Rollback()
+		var te *transitionError
+		if errors.As(err, &te) {
+			c.JSON(http.StatusConflict, gin.H{"error": te.Error(), "allowed": te.From.AllowedTransitions()})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post status: " + err.Error()})
+		return
+	}
+
+	event := notificationEvent{
+		Type:   models.NotificationPostStateChanged,
+		PostID: post.ID,
+		Payload: map[string]interface{}{
+			"post_id":    post.ID,
+			"title":      post.Title,
+			"from":       from,
+			"to":         input.State,
+			"note":       input.Note,
+			"actor_id":   adminID,
+			"actor_name": usernameOf(tx, adminID),
+		},
+	}
+	if err := notifyAdmins(tx, event); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification: " + err.Error()})
+		return
+	}
+	if err := recordAudit(tx, c, auditEntry{Action: "post.transition", EntityType: "post", EntityID: post.ID, Before: post.Status, After: status}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record audit log: " + err.Error()})
+		return
+	}
+	tx.Commit()
+
+	post.Status = status
+	c.Header("ETag", postETag(post))
+	c.JSON(http.StatusOK, gin.H{"message": "Post state updated successfully", "status": status})
+}
+
+// GetPostHistory handler: GET /posts/:id/history — riwayat transisi state, dari yang paling lama
+func GetPostHistory(c *gin.Context) {
+	var post models.Post
+	if err := config.DB.Select("id").First(&post, c.Param("id")).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post: " + err.Error()})
+		return
+	}
+
+	var history []models.StatusHistory
+	if err := config.DB.Preload("Actor").Where("post_id = ?", post.ID).
+		Order("created_at ASC, id ASC").Find(&history).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve status history: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, history)
+}
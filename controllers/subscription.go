@@ -0,0 +1,257 @@
+package controllers
+
+import (
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"filoti-backend/config"
+	"filoti-backend/matching"
+	"filoti-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+// Input untuk membuat/mengubah pencarian tersimpan
+type SubscriptionInput struct {
+	Name     string `json:"name"`
+	Query    string `json:"query"`
+	Ruangan  string `json:"ruangan"`
+	ItemType string `json:"itemType"`
+	Active   *bool  `json:"active"`
+}
+
+// validSubscriptionQuery menolak query yang hanya berisi stopword/tanda baca: tanpa token,
+// ContainsAll selalu true dan pencarian itu akan cocok dengan semua post baru
+func validSubscriptionQuery(query string) bool {
+	return query == "" || len(matching.Tokens(query)) > 0
+}
+
+func (in *SubscriptionInput) normalize() {
+	in.Name = strings.TrimSpace(in.Name)
+	in.Query = strings.TrimSpace(in.Query)
+	in.Ruangan = strings.TrimSpace(in.Ruangan)
+	in.ItemType = strings.TrimSpace(in.ItemType)
+}
+
+// GetSubscriptions handler: GET /me/subscriptions
+func GetSubscriptions(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var subs []models.Subscription
+	if err := config.DB.Where("user_id = ?", userID).Order("created_at DESC").Find(&subs).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve subscriptions: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, subs)
+}
+
+// CreateSubscription handler: POST /me/subscriptions
+func CreateSubscription(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var input SubscriptionInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	input.normalize()
+	if input.Query == "" && input.Ruangan == "" && input.ItemType == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "At least one of query, ruangan or itemType is required"})
+		return
+	}
+	if !validSubscriptionQuery(input.Query) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query must contain at least one searchable word"})
+		return
+	}
+
+	sub := models.Subscription{
+		UserID:   userID,
+		Name:     input.Name,
+		Query:    input.Query,
+		Ruangan:  input.Ruangan,
+		ItemType: input.ItemType,
+		Active:   true,
+	}
+	if sub.Name == "" {
+		sub.Name = subscriptionDefaultName(input)
+	}
+	if input.Active != nil {
+		sub.Active = *input.Active
+	}
+	// Select semua kolom agar Active=false tidak diganti default database
+	if err := config.DB.Select("*").Create(&sub).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create subscription: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, gin.H{"message": "Subscription created successfully", "subscription": sub})
+}
+
+// UpdateSubscription handler: PATCH /me/subscriptions/:id — hanya field yang dikirim yang diubah
+func UpdateSubscription(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var sub models.Subscription
+	if err := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).First(&sub).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve subscription: " + err.Error()})
+		return
+	}
+
+	var raw map[string]interface{}
+	if err := c.ShouldBindJSON(&raw); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	updates := map[string]interface{}{}
+	for field, column := range map[string]string{"name": "name", "query": "query", "ruangan": "ruangan", "itemType": "item_type"} {
+		if v, ok := raw[field]; ok {
+			str, isString := v.(string)
+			if !isString {
+				c.JSON(http.StatusBadRequest, gin.H{"error": field + " must be a string"})
+				return
+			}
+			updates[column] = strings.TrimSpace(str)
+		}
+	}
+	if q, ok := updates["query"].(string); ok && !validSubscriptionQuery(q) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query must contain at least one searchable word"})
+		return
+	}
+	if v, ok := raw["active"]; ok {
+		active, isBool := v.(bool)
+		if !isBool {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "active must be a boolean"})
+			return
+		}
+		updates["active"] = active
+	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+
+	if err := config.DB.Model(&sub).Updates(updates).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update subscription: " + err.Error()})
+		return
+	}
+	config.DB.First(&sub, sub.ID)
+	if sub.Query == "" && sub.Ruangan == "" && sub.ItemType == "" {
+		// Kriteria kosong akan cocok dengan semua post; nonaktifkan daripada membanjiri notifikasi
+		config.DB.Model(&sub).Update("active", false)
+		sub.Active = false
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Subscription updated successfully", "subscription": sub})
+}
+
+// DeleteSubscription handler: DELETE /me/subscriptions/:id
+func DeleteSubscription(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	result := config.DB.Where("id = ? AND user_id = ?", c.Param("id"), userID).Delete(&models.Subscription{})
+	if result.Error != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete subscription: " + result.Error.Error()})
+		return
+	}
+	if result.RowsAffected == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "Subscription not found"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Subscription deleted"})
+}
+
+func subscriptionDefaultName(in SubscriptionInput) string {
+	var parts []string
+	for _, p := range []string{in.Query, in.Ruangan, in.ItemType} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// notifySubscribers dijalankan setelah post baru di-commit: mengevaluasi pencarian tersimpan
+// dan mengirim satu notifikasi per user yang cocok. Error hanya di-log.
+func notifySubscribers(db *gorm.DB, post models.Post) {
+	var subs []models.Subscription
+	// Filter ruangan (substring) dilakukan di Go: nilai kolom ruangan tidak boleh jadi pola LIKE
+	// karena % dan _ di dalamnya akan dianggap wildcard
+	query := db.Where("active = ?", true).
+		Where("(item_type = '' OR lower(item_type) = lower(?))", post.ItemType)
+	if post.AuthorID != nil {
+		query = query.Where("user_id <> ?", *post.AuthorID)
+	}
+	if err := query.Order("id ASC").Find(&subs).Error; err != nil {
+		log.Printf("notifySubscribers: failed to load subscriptions - %v", err)
+		return
+	}
+	postRuangan := strings.ToLower(post.Ruangan)
+	filtered := subs[:0]
+	for _, sub := range subs {
+		if !validSubscriptionQuery(sub.Query) {
+			continue // Data lama yang tersimpan sebelum validasi query
+		}
+		if sub.Ruangan == "" || strings.Contains(postRuangan, strings.ToLower(sub.Ruangan)) {
+			filtered = append(filtered, sub)
+		}
+	}
+	subs = filtered
+	if len(subs) == 0 {
+		return
+	}
+
+	postTokens := matching.Tokens(post.Title + " " + post.Keterangan + " " + post.Ruangan)
+	notified := map[uint]bool{}
+	var matchedIDs []uint
+	err := db.Transaction(func(tx *gorm.DB) error {
+		for _, sub := range subs {
+			if notified[sub.UserID] || !matching.ContainsAll(postTokens, matching.Tokens(sub.Query)) {
+				continue
+			}
+			notified[sub.UserID] = true
+			matchedIDs = append(matchedIDs, sub.ID)
+			if err := notifyUsers(tx, []uint{sub.UserID}, notificationEvent{
+				Type:   models.NotificationSubscriptionMatch,
+				PostID: post.ID,
+				Payload: map[string]interface{}{
+					"post_id":           post.ID,
+					"title":             post.Title,
+					"ruangan":           post.Ruangan,
+					"item_type":         post.ItemType,
+					"subscription_id":   sub.ID,
+					"subscription_name": sub.Name,
+				},
+			}); err != nil {
+				return err
+			}
+		}
+		if len(matchedIDs) == 0 {
+			return nil
+		}
+		return tx.Model(&models.Subscription{}).Where("id IN ?", matchedIDs).Update("last_matched_at", time.Now()).Error
+	})
+	if err != nil {
+		log.Printf("notifySubscribers: failed to notify subscribers of post %d - %v", post.ID, err)
+	}
+}
@@ -0,0 +1,290 @@
+package controllers
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"filoti-backend/authtoken"
+	"filoti-backend/config"
+	"filoti-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Input untuk POST /auth/token
+type TokenInput struct {
+	GrantType    string `json:"grant_type" binding:"required"` // "password" atau "refresh_token"
+	Username     string `json:"username"`
+	Password     string `json:"password"`
+	RefreshToken string `json:"refresh_token"`
+	MFAToken     string `json:"mfa_token"` // grant_type=mfa
+	Code         string `json:"code"`
+}
+
+// tokenError adalah penolakan yang dikembalikan ke klien apa adanya
+type tokenError struct {
+	status  int
+	message string
+}
+
+func (e *tokenError) Error() string { return e.message }
+
+var errInvalidRefreshToken = &tokenError{http.StatusUnauthorized, "Invalid or expired refresh token"}
+
+// IssueToken handler: POST /auth/token — menerbitkan access token + refresh token untuk klien non-browser.
+//
+//   - grant_type=password: username dan password seperti /login
+//   - grant_type=refresh_token: menukar refresh token lama dengan pasangan token baru (rotasi)
+//   - grant_type=mfa: mfa_token dari grant password ditambah kode 2FA, untuk akun dengan 2FA aktif
+func IssueToken(c *gin.Context) {
+	if config.Tokens == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Token authentication is not enabled"})
+		return
+	}
+
+	var input TokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	switch input.GrantType {
+	case "password":
+		issueTokenForPassword(c, input)
+	case "refresh_token":
+		if !allowRequest(c, "token_refresh", refreshRateRule) {
+			return
+		}
+		refreshToken(c, strings.TrimSpace(input.RefreshToken))
+	case "mfa":
+		if user, ok := completeLoginChallenge(c, input.MFAToken, input.Code); ok {
+			issueTokenFamily(c, user)
+		}
+	default:
+		c.JSON(http.StatusBadRequest, gin.H{"error": "grant_type must be 'password', 'refresh_token' or 'mfa'"})
+	}
+}
+
+func issueTokenForPassword(c *gin.Context, input TokenInput) {
+	username := strings.ToLower(strings.TrimSpace(input.Username))
+	password := strings.TrimSpace(input.Password) // Sama seperti Login
+	if username == "" || password == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "username and password are required"})
+		return
+	}
+	if !allowLoginAttempt(c, username) {
+		return
+	}
+
+	var user models.User
+	if err := config.DB.Where("username = ?", username).First(&user).Error; err != nil {
+		recordAuditBestEffort(c, auditEntry{Action: "auth.login_failed", EntityType: "user", EntityID: username})
+		recordLoginFailure(c, username)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		return
+	}
+	if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(password)); err != nil {
+		recordAuditBestEffort(c, auditEntry{Action: "auth.login_failed", EntityType: "user", EntityID: user.ID})
+		recordLoginFailure(c, username)
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid username or password"})
+		return
+	}
+	if user.IsSuspended() {
+		recordAuditBestEffort(c, auditEntry{Action: "auth.login_blocked", EntityType: "user", EntityID: user.ID, ActorID: user.ID})
+		c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended", "reason": user.SuspendedReason})
+		return
+	}
+	if user.TwoFactorEnabled() {
+		startLoginChallenge(c, user) // recordLoginSuccess menunggu kode 2FA di grant_type=mfa
+		return
+	}
+	recordLoginSuccess(c, username)
+	issueTokenFamily(c, user)
+}
+
+// issueTokenFamily menerbitkan access token dan refresh token pertama dari family baru
+func issueTokenFamily(c *gin.Context, user models.User) {
+	familyID, err := authtoken.RandomToken(16)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue token: " + err.Error()})
+		return
+	}
+	var refresh string
+	err = config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		if refresh, _, err = createRefreshToken(tx, c, user.ID, familyID); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, auditEntry{Action: "auth.token_issued", EntityType: "user", EntityID: user.ID, ActorID: user.ID})
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue token: " + err.Error()})
+		return
+	}
+	writeTokenResponse(c, user, refresh)
+}
+
+// refreshToken merotasi refresh token. Token yang sudah pernah dipakai menandakan kebocoran,
+// sehingga seluruh family-nya di-revoke dan klien harus login ulang.
+func refreshToken(c *gin.Context, presented string) {
+	if presented == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "refresh_token is required"})
+		return
+	}
+
+	var user models.User
+	var refresh string
+	reused := false
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var current models.RefreshToken
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("token_hash = ?", authtoken.HashToken(presented)).First(&current).Error; err != nil {
+			if err == gorm.ErrRecordNotFound {
+				return errInvalidRefreshToken
+			}
+			return err
+		}
+
+		if current.RevokedAt != nil {
+			if err := revokeTokenFamily(tx, current.FamilyID); err != nil {
+				return err
+			}
+			if err := recordAudit(tx, c, auditEntry{
+				Action:     "auth.refresh_reuse",
+				EntityType: "refresh_token_family",
+				EntityID:   current.FamilyID,
+				ActorID:    current.UserID,
+			}); err != nil {
+				return err
+			}
+			reused = true // Commit revoke family, lalu tolak klien di luar transaksi
+			return nil
+		}
+		if time.Now().After(current.ExpiresAt) {
+			return errInvalidRefreshToken
+		}
+
+		if err := tx.First(&user, current.UserID).Error; err != nil {
+			return errInvalidRefreshToken
+		}
+		if user.IsSuspended() {
+			if err := revokeTokenFamily(tx, current.FamilyID); err != nil {
+				return err
+			}
+			return &tokenError{http.StatusForbidden, "Account suspended"}
+		}
+
+		var next models.RefreshToken
+		var err error
+		if refresh, next, err = createRefreshToken(tx, c, user.ID, current.FamilyID); err != nil {
+			return err
+		}
+		return tx.Model(&current).Updates(map[string]interface{}{
+			"revoked_at":     time.Now(),
+			"replaced_by_id": next.ID,
+		}).Error
+	})
+	if err == nil && reused {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Refresh token has already been used; all sessions from this login were revoked"})
+		return
+	}
+	if err != nil {
+		if te, ok := err.(*tokenError); ok {
+			c.JSON(te.status, gin.H{"error": te.message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to refresh token: " + err.Error()})
+		return
+	}
+	writeTokenResponse(c, user, refresh)
+}
+
+// Input untuk POST /auth/revoke
+type RevokeTokenInput struct {
+	RefreshToken string `json:"refresh_token" binding:"required"`
+}
+
+// RevokeToken handler: POST /auth/revoke — logout untuk klien token; me-revoke seluruh family refresh token.
+// Selalu 200 walau token tidak dikenal (RFC 7009), agar tidak bisa dipakai menebak token.
+func RevokeToken(c *gin.Context) {
+	var input RevokeTokenInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var token models.RefreshToken
+	if err := config.DB.Where("token_hash = ?", authtoken.HashToken(strings.TrimSpace(input.RefreshToken))).
+		First(&token).Error; err == nil {
+		if err := revokeTokenFamily(config.DB, token.FamilyID); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to revoke token: " + err.Error()})
+			return
+		}
+		recordAuditBestEffort(c, auditEntry{Action: "auth.logout", EntityType: "user", EntityID: token.UserID, ActorID: token.UserID})
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Token revoked"})
+}
+
+// createRefreshToken menyimpan refresh token baru (hash-nya saja) dan mengembalikan nilai aslinya
+func createRefreshToken(tx *gorm.DB, c *gin.Context, userID uint, familyID string) (string, models.RefreshToken, error) {
+	value, err := authtoken.RandomToken(32)
+	if err != nil {
+		return "", models.RefreshToken{}, err
+	}
+	token := models.RefreshToken{
+		UserID:    userID,
+		FamilyID:  familyID,
+		TokenHash: authtoken.HashToken(value),
+		ExpiresAt: time.Now().Add(config.Tokens.RefreshTTL),
+		IP:        c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+	if err := tx.Create(&token).Error; err != nil {
+		return "", token, err
+	}
+	return value, token, nil
+}
+
+// revokeTokenFamily me-revoke semua refresh token aktif dalam satu family
+func revokeTokenFamily(tx *gorm.DB, familyID string) error {
+	return tx.Model(&models.RefreshToken{}).
+		Where("family_id = ? AND revoked_at IS NULL", familyID).
+		Update("revoked_at", time.Now()).Error
+}
+
+func writeTokenResponse(c *gin.Context, user models.User, refresh string) {
+	body, err := tokenResponse(user, refresh)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to issue token: " + err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, body)
+}
+
+// tokenResponse menerbitkan access token baru dan menyusun body respons token bersama refresh token
+func tokenResponse(user models.User, refresh string) (gin.H, error) {
+	access, expiresAt, err := config.Tokens.IssueAccess(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	return gin.H{
+		"access_token":         access,
+		"token_type":           "Bearer",
+		"expires_in":           int(time.Until(expiresAt).Seconds()),
+		"refresh_token":        refresh,
+		"refresh_expires_in":   int(config.Tokens.RefreshTTL.Seconds()),
+		"must_change_password": user.MustChangePassword,
+
+		"two_factor_setup_required": config.TwoFactorRequired(user) && !user.TwoFactorEnabled(),
+		"user": gin.H{
+			"id":       user.ID,
+			"username": user.Username,
+			"role":     user.Role,
+		},
+	}, nil
+}
@@ -0,0 +1,254 @@
+package controllers
+
+import (
+	"crypto/subtle"
+	"log"
+	"net/http"
+	"os"
+	"strconv"
+	"time"
+
+	"filoti-backend/config"
+	"filoti-backend/models"
+	"filoti-backend/sessionstore"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const defaultTrashRetentionDays = 30
+
+// trashRetentionDays membaca TRASH_RETENTION_DAYS (default 30 hari)
+func trashRetentionDays() int {
+	if v := os.Getenv("TRASH_RETENTION_DAYS"); v != "" {
+		if days, err := strconv.Atoi(v); err == nil && days > 0 {
+			return days
+		}
+		log.Printf("Invalid TRASH_RETENTION_DAYS %q, using %d", v, defaultTrashRetentionDays)
+	}
+	return defaultTrashRetentionDays
+}
+
+// purgeTrash menghapus permanen post yang sudah lebih lama dari masa retensi di tempat sampah.
+// Status dan saran kecocokan ikut terhapus lewat ON DELETE CASCADE; klaim dan riwayat status
+// disimpan sebagai arsip. File gambar post dihapus dari storage jika tidak dipakai data lain.
+func purgeTrash(db *gorm.DB) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -trashRetentionDays())
+	var posts []models.Post
+	if err := db.Unscoped().Select("id", "image_url").
+		Where("deleted_at IS NOT NULL AND deleted_at < ?", cutoff).Find(&posts).Error; err != nil {
+		return 0, err
+	}
+	if len(posts) == 0 {
+		return 0, nil
+	}
+	ids := make([]uint, 0, len(posts))
+	var urls []string
+	for _, p := range posts {
+		ids = append(ids, p.ID)
+		if p.ImageURL != "" {
+			urls = append(urls, p.ImageURL)
+		}
+	}
+
+	// Kondisi deleted_at diulang agar post yang baru saja dipulihkan tidak ikut terhapus
+	result := db.Unscoped().Where("id IN ? AND deleted_at IS NOT NULL AND deleted_at < ?", ids, cutoff).Delete(&models.Post{})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	deleteUnusedUploads(db, urls)
+	return result.RowsAffected, nil
+}
+
+// GetTrash handler: GET /admin/trash — post yang dihapus, terbaru lebih dulu (cursor pagination)
+func GetTrash(c *gin.Context) {
+	limit, err := parseLimit(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	query := config.DB.Unscoped().Model(&models.Post{}).Where("posts.deleted_at IS NOT NULL")
+	if cursorParam := c.Query("cursor"); cursorParam != "" {
+		cursor, err := decodeCursor(cursorParam)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		query = query.Where("(posts.deleted_at, posts.id) < (?, ?)", cursor.CreatedAt, cursor.ID)
+	}
+
+	var posts []models.Post
+	if err := query.Preload("Status").Preload("Author").Preload("Deleter").
+		Order("posts.deleted_at DESC, posts.id DESC").Limit(limit + 1).Find(&posts).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve trash: " + err.Error()})
+		return
+	}
+
+	hasMore := len(posts) > limit
+	if hasMore {
+		posts = posts[:limit]
+	}
+	attachImageVariants(config.DB, posts)
+
+	retention := trashRetentionDays()
+	items := make([]gin.H, 0, len(posts))
+	for _, p := range posts {
+		item := postListItem(p)
+		var deletedByName interface{}
+		if p.Deleter != nil {
+			deletedByName = p.Deleter.Username
+		}
+		item["deleted_at"] = p.DeletedAt.Time
+		item["deleted_by"] = p.DeletedBy
+		item["deleted_by_name"] = deletedByName
+		item["purge_at"] = p.DeletedAt.Time.AddDate(0, 0, retention)
+		items = append(items, item)
+	}
+
+	var nextCursor interface{}
+	if hasMore {
+		last := posts[len(posts)-1]
+		nextCursor = encodeCursor(last.DeletedAt.Time, last.ID)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"posts":          items,
+		"next_cursor":    nextCursor,
+		"has_more":       hasMore,
+		"limit":          limit,
+		"retention_days": retention,
+	})
+}
+
+// RestorePost handler: POST /posts/:id/restore — mengembalikan post dari tempat sampah (admin)
+func RestorePost(c *gin.Context) {
+	adminID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	postID, ok := parsePostID(c)
+	if !ok {
+		return
+	}
+
+	tx := config.DB.Begin()
+
+	var post models.Post
+	if err := tx.Unscoped().Clauses(clause.Locking{Strength: "UPDATE"}).First(&post, postID).Error; err != nil {
+		tx.Rollback()
+		if err == gorm.ErrRecordNotFound {
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post: " + err.Error()})
+		return
+	}
+	if !post.DeletedAt.Valid {
+		tx.Rollback()
+		c.JSON(http.StatusConflict, gin.H{"error": "Post is not in the trash"})
+		return
+	}
+
+	if err := tx.Unscoped().Model(&post).Updates(map[string]interface{}{
+		"deleted_at": nil,
+		"deleted_by": nil,
+		"version":    gorm.Expr("version + 1"),
+	}).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to restore post: " + err.Error()})
+		return
+	}
+
+	event := notificationEvent{
+		Type:   models.NotificationPostRestored,
+		PostID: post.ID,
+		Payload: map[string]interface{}{
+			"post_id":    post.ID,
+			"title":      post.Title,
+			"actor_id":   adminID,
+			"actor_name": usernameOf(tx, adminID),
+		},
+	}
+	if err := notifyAdmins(tx, event); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification: " + err.Error()})
+		return
+	}
+	if err := recordAudit(tx, c, auditEntry{
+		Action:     "post.restore",
+		EntityType: "post",
+		EntityID:   post.ID,
+		Before:     gin.H{"deleted_at": post.DeletedAt, "deleted_by": post.DeletedBy},
+		After:      gin.H{"deleted_at": nil, "deleted_by": nil},
+	}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record audit log: " + err.Error()})
+		return
+	}
+	tx.Commit()
+
+	if err := config.DB.Preload("Status").Preload("Author").First(&post, post.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post after restore: " + err.Error()})
+		return
+	}
+	attachPostImageVariants(config.DB, &post)
+
+	c.Header("ETag", postETag(post))
+	c.JSON(http.StatusOK, gin.H{"message": "Post restored successfully", "post": post})
+}
+
+// PurgeTrash handler: POST /admin/trash/purge — menjalankan purge secara manual
+func PurgeTrash(c *gin.Context) {
+	purged, err := purgeTrash(config.DB)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to purge trash: " + err.Error()})
+		return
+	}
+	recordAuditBestEffort(c, auditEntry{
+		Action:     "post.purge",
+		EntityType: "post",
+		After:      gin.H{"purged": purged, "retention_days": trashRetentionDays()},
+	})
+	c.JSON(http.StatusOK, gin.H{"message": "Trash purged", "purged": purged, "retention_days": trashRetentionDays()})
+}
+
+// CronPurgeTrash handler: GET /cron/purge-trash — dipanggil Vercel Cron (lihat vercel.json).
+// Vercel mengirim header "Authorization: Bearer <CRON_SECRET>". Session kedaluwarsa ikut dibersihkan.
+func CronPurgeTrash(c *gin.Context) {
+	secret := os.Getenv("CRON_SECRET")
+	if secret == "" {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "CRON_SECRET is not configured"})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(c.GetHeader("Authorization")), []byte("Bearer "+secret)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	purgeSessions()
+	PurgeTrash(c)
+}
+
+// purgeSessions menghapus session kedaluwarsa/di-revoke; error hanya di-log agar purge trash tetap jalan
+func purgeSessions() {
+	if purged, err := sessionstore.Purge(config.DB); err != nil {
+		log.Printf("Session purge failed: %v", err)
+	} else if purged > 0 {
+		log.Printf("Session purge removed %d sessions", purged)
+	}
+}
+
+// RunTrashPurger menjalankan purge secara berkala untuk server yang berjalan terus (bukan serverless)
+func RunTrashPurger(interval time.Duration) {
+	for {
+		purgeSessions()
+		if purged, err := purgeTrash(config.DB); err != nil {
+			log.Printf("Trash purge failed: %v", err)
+		} else if purged > 0 {
+			log.Printf("Trash purge removed %d posts", purged)
+		}
+		time.Sleep(interval)
+	}
+}
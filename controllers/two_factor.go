@@ -0,0 +1,407 @@
+package controllers
+
+import (
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"filoti-backend/authtoken"
+	"filoti-backend/config"
+	"filoti-backend/models"
+	"filoti-backend/totp"
+
+	"github.com/gin-gonic/gin"
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const (
+	recoveryCodeCount   = 10
+	loginChallengeTTL   = 5 * time.Minute
+	totpSkew            = 1 // Terima kode satu periode sebelum/sesudah untuk jam yang sedikit meleset
+	recoveryCodeHalfLen = 5
+)
+
+var errInvalidSecondFactor = &tokenError{http.StatusUnauthorized, "Invalid two-factor code"}
+
+// totpIssuer adalah nama yang tampil di aplikasi authenticator (TOTP_ISSUER)
+func totpIssuer() string {
+	if v := os.Getenv("TOTP_ISSUER"); v != "" {
+		return v
+	}
+	return "FILOTI"
+}
+
+// normalizeRecoveryCode menyamakan format kode cadangan (huruf besar/kecil, tanda hubung, spasi)
+func normalizeRecoveryCode(code string) string {
+	code = strings.ReplaceAll(strings.ReplaceAll(strings.TrimSpace(code), "-", ""), " ", "")
+	return strings.ToLower(code)
+}
+
+// generateRecoveryCodes mengganti semua kode cadangan user dan mengembalikan kode barunya (format xxxxx-xxxxx)
+func generateRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error; err != nil {
+		return nil, err
+	}
+	codes := make([]string, 0, recoveryCodeCount)
+	rows := make([]models.RecoveryCode, 0, recoveryCodeCount)
+	for i := 0; i < recoveryCodeCount; i++ {
+		raw, err := randomString("abcdefghjkmnpqrstuvwxyz23456789", 2*recoveryCodeHalfLen)
+		if err != nil {
+			return nil, err
+		}
+		codes = append(codes, raw[:recoveryCodeHalfLen]+"-"+raw[recoveryCodeHalfLen:])
+		rows = append(rows, models.RecoveryCode{UserID: userID, CodeHash: authtoken.HashToken(raw)})
+	}
+	if err := tx.Create(&rows).Error; err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// verifySecondFactor menerima kode TOTP atau kode cadangan. user harus sudah dikunci FOR UPDATE
+// di tx agar kode yang sama tidak bisa dipakai dua kali secara bersamaan.
+func verifySecondFactor(tx *gorm.DB, user *models.User, code string) error {
+	if user.TOTPSecret == "" {
+		return errInvalidSecondFactor
+	}
+	if step, ok := totp.Validate(user.TOTPSecret, code, time.Now(), totpSkew); ok {
+		if step <= user.TOTPLastStep {
+			return errInvalidSecondFactor // Kode ini sudah pernah dipakai
+		}
+		user.TOTPLastStep = step
+		return tx.Model(user).Update("totp_last_step", step).Error
+	}
+
+	result := tx.Model(&models.RecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", user.ID, authtoken.HashToken(normalizeRecoveryCode(code))).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return errInvalidSecondFactor
+	}
+	return nil
+}
+
+// lockUser memuat user dengan kunci baris untuk operasi 2FA
+func lockUser(tx *gorm.DB, userID uint) (models.User, error) {
+	var user models.User
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&user, userID).Error
+	return user, err
+}
+
+// startLoginChallenge membalas login yang password-nya benar tetapi masih memerlukan kode 2FA.
+// mfa_token ditukar bersama kode di POST /login/2fa atau grant_type=mfa di /auth/token.
+func startLoginChallenge(c *gin.Context, user models.User) {
+	var raw string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		var err error
+		raw, err = createUserToken(tx, user.ID, models.TokenLoginChallenge, "", loginChallengeTTL)
+		return err
+	})
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to start two-factor login: " + err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"message":             "Two-factor code required",
+		"two_factor_required": true,
+		"mfa_token":           raw,
+		"expires_in":          int(loginChallengeTTL.Seconds()),
+	})
+}
+
+// completeLoginChallenge menukar mfa_token dan kode 2FA dengan user yang login.
+// ok=false berarti respons error sudah dikirim.
+func completeLoginChallenge(c *gin.Context, mfaToken, code string) (models.User, bool) {
+	var user models.User
+	mfaToken = strings.TrimSpace(mfaToken)
+	if mfaToken == "" || strings.TrimSpace(code) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "mfa_token and code are required"})
+		return user, false
+	}
+
+	// Cari pemilik token dulu agar lockout per username juga berlaku untuk tebakan kode
+	var challenge models.UserToken
+	if err := config.DB.Preload("User").
+		Where("token_hash = ? AND purpose = ?", authtoken.HashToken(mfaToken), models.TokenLoginChallenge).
+		First(&challenge).Error; err != nil || challenge.User == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired two-factor challenge"})
+		return user, false
+	}
+	username := challenge.User.Username
+	if !allowLoginAttempt(c, username) {
+		return user, false
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := consumeUserToken(tx, mfaToken, models.TokenLoginChallenge); err != nil {
+			return &tokenError{http.StatusUnauthorized, "Invalid or expired two-factor challenge"}
+		}
+		var err error
+		if user, err = lockUser(tx, challenge.UserID); err != nil {
+			return err
+		}
+		if user.IsSuspended() {
+			return &tokenError{http.StatusForbidden, "Account suspended"}
+		}
+		return verifySecondFactor(tx, &user, code)
+	})
+	if err != nil {
+		if te, ok := err.(*tokenError); ok {
+			if te == errInvalidSecondFactor {
+				recordAuditBestEffort(c, auditEntry{Action: "auth.login_failed", EntityType: "user", EntityID: user.ID,
+					After: gin.H{"reason": "invalid_2fa_code"}})
+				recordLoginFailure(c, username)
+			}
+			c.JSON(te.status, gin.H{"error": te.message})
+			return user, false
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to verify two-factor code: " + err.Error()})
+		return user, false
+	}
+	recordLoginSuccess(c, username)
+	return user, true
+}
+
+// Input untuk POST /login/2fa
+type LoginTwoFactorInput struct {
+	MFAToken string `json:"mfa_token" binding:"required"`
+	Code     string `json:"code" binding:"required"` // Kode TOTP 6 digit atau kode cadangan
+}
+
+// LoginTwoFactor handler: POST /login/2fa — langkah kedua login untuk akun dengan 2FA aktif
+func LoginTwoFactor(c *gin.Context) {
+	var input LoginTwoFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	user, ok := completeLoginChallenge(c, input.MFAToken, input.Code)
+	if !ok {
+		return
+	}
+	startSession(c, user)
+}
+
+// SetupTwoFactor handler: POST /me/2fa/setup — membuat secret baru dan URI untuk QR code.
+// 2FA belum aktif sampai kode pertama dikonfirmasi lewat /me/2fa/enable.
+func SetupTwoFactor(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var user models.User
+	if err := config.DB.First(&user, userID).Error; err != nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "User not found"})
+		return
+	}
+	if user.TwoFactorEnabled() {
+		c.JSON(http.StatusConflict, gin.H{"error": "Two-factor authentication is already enabled"})
+		return
+	}
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate secret: " + err.Error()})
+		return
+	}
+	if err := config.DB.Model(&user).Updates(map[string]interface{}{
+		"totp_secret":    secret,
+		"totp_last_step": 0,
+	}).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save secret: " + err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{
+		"secret":           secret,
+		"provisioning_uri": totp.ProvisioningURI(totpIssuer(), user.Username, secret),
+		"digits":           totp.Digits,
+		"period":           totp.Period,
+	})
+}
+
+// Input untuk POST /me/2fa/enable dan /me/2fa/recovery-codes
+type TwoFactorCodeInput struct {
+	Code string `json:"code" binding:"required"`
+}
+
+// EnableTwoFactor handler: POST /me/2fa/enable — mengonfirmasi kode pertama dari authenticator,
+// mengaktifkan 2FA dan mengembalikan kode cadangan (hanya ditampilkan sekali)
+func EnableTwoFactor(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var input TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := lockUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if user.TwoFactorEnabled() {
+			return &tokenError{http.StatusConflict, "Two-factor authentication is already enabled"}
+		}
+		if user.TOTPSecret == "" {
+			return &tokenError{http.StatusBadRequest, "Call /me/2fa/setup first"}
+		}
+		// Hanya kode TOTP yang diterima di sini; kode cadangan belum ada
+		step, valid := totp.Validate(user.TOTPSecret, input.Code, time.Now(), totpSkew)
+		if !valid {
+			return errInvalidSecondFactor
+		}
+		if err := tx.Model(&user).Updates(map[string]interface{}{
+			"totp_enabled_at": time.Now(),
+			"totp_last_step":  step,
+		}).Error; err != nil {
+			return err
+		}
+		if codes, err = generateRecoveryCodes(tx, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, auditEntry{Action: "user.2fa_enable", EntityType: "user", EntityID: user.ID})
+	})
+	if err != nil {
+		if te, ok := err.(*tokenError); ok {
+			c.JSON(te.status, gin.H{"error": te.message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to enable two-factor authentication: " + err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication enabled", "recovery_codes": codes})
+}
+
+// RegenerateRecoveryCodes handler: POST /me/2fa/recovery-codes — mengganti semua kode cadangan
+func RegenerateRecoveryCodes(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var input TwoFactorCodeInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var codes []string
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := lockUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !user.TwoFactorEnabled() {
+			return &tokenError{http.StatusBadRequest, "Two-factor authentication is not enabled"}
+		}
+		if err := verifySecondFactor(tx, &user, input.Code); err != nil {
+			return err
+		}
+		if codes, err = generateRecoveryCodes(tx, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, auditEntry{Action: "user.2fa_recovery_codes", EntityType: "user", EntityID: user.ID})
+	})
+	if err != nil {
+		if te, ok := err.(*tokenError); ok {
+			c.JSON(te.status, gin.H{"error": te.message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to regenerate recovery codes: " + err.Error()})
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(http.StatusOK, gin.H{"recovery_codes": codes})
+}
+
+// Input untuk POST /me/2fa/disable
+type DisableTwoFactorInput struct {
+	Password string `json:"password" binding:"required"`
+	Code     string `json:"code" binding:"required"`
+}
+
+// DisableTwoFactor handler: POST /me/2fa/disable — mematikan 2FA dengan password dan kode 2FA.
+// Ditolak jika kebijakan REQUIRE_ADMIN_2FA mewajibkan 2FA untuk role user.
+func DisableTwoFactor(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+	var input DisableTwoFactorInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	err := config.DB.Transaction(func(tx *gorm.DB) error {
+		user, err := lockUser(tx, userID)
+		if err != nil {
+			return err
+		}
+		if !user.TwoFactorEnabled() {
+			return &tokenError{http.StatusBadRequest, "Two-factor authentication is not enabled"}
+		}
+		if config.TwoFactorRequired(user) {
+			return &tokenError{http.StatusForbidden, "Two-factor authentication is required for your role"}
+		}
+		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(strings.TrimSpace(input.Password))); err != nil {
+			return &tokenError{http.StatusUnauthorized, "Password is incorrect"}
+		}
+		if err := verifySecondFactor(tx, &user, input.Code); err != nil {
+			return err
+		}
+		if err := clearTwoFactor(tx, user.ID); err != nil {
+			return err
+		}
+		return recordAudit(tx, c, auditEntry{Action: "user.2fa_disable", EntityType: "user", EntityID: user.ID})
+	})
+	if err != nil {
+		if te, ok := err.(*tokenError); ok {
+			c.JSON(te.status, gin.H{"error": te.message})
+			return
+		}
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to disable two-factor authentication: " + err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"message": "Two-factor authentication disabled"})
+}
+
+// ResetUserTwoFactor handler: POST /admin/users/:id/2fa/reset — untuk user yang kehilangan
+// authenticator sekaligus kode cadangannya. Semua session user ikut di-revoke.
+func ResetUserTwoFactor(c *gin.Context) {
+	manageUser(c, "user.2fa_reset", "Failed to reset two-factor authentication", func(tx *gorm.DB, user *models.User) error {
+		if err := clearTwoFactor(tx, user.ID); err != nil {
+			return err
+		}
+		user.TOTPSecret = ""
+		user.TOTPEnabledAt = nil
+		return revokeUserAccess(tx, user.ID, "")
+	})
+}
+
+// clearTwoFactor menghapus secret TOTP dan kode cadangan user
+func clearTwoFactor(tx *gorm.DB, userID uint) error {
+	if err := tx.Model(&models.User{}).Where("id = ?", userID).Updates(map[string]interface{}{
+		"totp_secret":     "",
+		"totp_enabled_at": nil,
+		"totp_last_step":  0,
+	}).Error; err != nil {
+		return err
+	}
+	return tx.Where("user_id = ?", userID).Delete(&models.RecoveryCode{}).Error
+}
@@ -1,10 +1,14 @@
 package controllers
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
-	"strconv" // Tambahkan import strconv
-	"time"    // Tambahkan import time
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
 
 	"filoti-backend/config"
 	"filoti-backend/models"
@@ -15,44 +19,138 @@ import (
 
 // ... (CreatePostInput, CreatePost, GetPosts, GetPostByID, GetUniqueLocations - kode yang sudah ada) ...
 
-// Input untuk mengupdate post
+// Input untuk mengupdate post lewat PUT (field kosong dianggap tidak diubah)
 type UpdatePostInput struct {
 	Title      string `json:"title"`
 	Keterangan string `json:"keterangan"`
 	Ruangan    string `json:"ruangan"`
 	ImageURL   string `json:"image_url"` // Bisa diupdate juga
-	// ItemType tidak diupdate karena tidak seharusnya berubah
+	ItemType   string `json:"itemType"`
 }
 
-// UpdatePost handler: memerlukan AuthRequired middleware dan id post
+// postPatchField menjelaskan satu field post yang boleh diubah lewat PATCH
+type postPatchField struct {
+	Column   string
+	Required bool // Tidak boleh null atau string kosong
+	MaxLen   int
+}
+
+// Field yang boleh diubah; nama mengikuti JSON pada models.Post
+var postPatchFields = map[string]postPatchField{
+	"title":      {Column: "title", Required: true, MaxLen: 200},
+	"keterangan": {Column: "keterangan", MaxLen: 5000},
+	"ruangan":    {Column: "ruangan", Required: true, MaxLen: 100},
+	"image_url":  {Column: "image_url", MaxLen: 2048},
+	"itemType":   {Column: "item_type", Required: true, MaxLen: 50},
+}
+
+// parsePostPatch mengubah dokumen JSON merge-patch (RFC 7396) menjadi map kolom -> nilai.
+// null menghapus isi field opsional (menjadi string kosong); field wajib menolak null.
+func parsePostPatch(body map[string]json.RawMessage) (map[string]interface{}, error) {
+	updates := make(map[string]interface{}, len(body))
+	for name, raw := range body {
+		field, ok := postPatchFields[name]
+		if !ok {
+			return nil, fmt.Errorf("field '%s' cannot be updated", name)
+		}
+		if string(raw) == "null" {
+			if field.Required {
+				return nil, fmt.Errorf("field '%s' cannot be null", name)
+			}
+			updates[field.Column] = ""
+			continue
+		}
+		var value string
+		if err := json.Unmarshal(raw, &value); err != nil {
+			return nil, fmt.Errorf("field '%s' must be a string or null", name)
+		}
+		value = strings.TrimSpace(value)
+		if field.Required && value == "" {
+			return nil, fmt.Errorf("field '%s' cannot be empty", name)
+		}
+		if field.MaxLen > 0 && utf8.RuneCountInString(value) > field.MaxLen {
+			return nil, fmt.Errorf("field '%s' must be at most %d characters", name, field.MaxLen)
+		}
+		updates[field.Column] = value
+	}
+	return updates, nil
+}
+
+// UpdatePost handler: PUT /posts/:id (izin posts:update). Field yang kosong/tidak dikirim tidak diubah;
+// gunakan PATCH untuk mengosongkan field.
 func UpdatePost(c *gin.Context) {
-	// Pastikan user admin yang login
-	uidVal, exists := c.Get("userID")
-	if !exists {
+	var input UpdatePostInput
+	if err := c.ShouldBindJSON(&input); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	body := map[string]json.RawMessage{}
+	for name, value := range map[string]string{
+		"title":      input.Title,
+		"keterangan": input.Keterangan,
+		"ruangan":    input.Ruangan,
+		"image_url":  input.ImageURL,
+		"itemType":   input.ItemType,
+	} {
+		if value != "" {
+			body[name], _ = json.Marshal(value)
+		}
+	}
+	applyPostUpdate(c, body)
+}
+
+// PatchPost handler: PATCH /posts/:id dengan semantik JSON merge-patch.
+// Hanya field yang dikirim yang diubah; null mengosongkan field opsional.
+func PatchPost(c *gin.Context) {
+	var body map[string]json.RawMessage
+	decoder := json.NewDecoder(c.Request.Body)
+	if err := decoder.Decode(&body); err != nil || body == nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request body must be a JSON object"})
+		return
+	}
+	if decoder.More() {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Request body must contain a single JSON object"})
+		return
+	}
+	applyPostUpdate(c, body)
+}
+
+// applyPostUpdate dipakai bersama oleh PUT dan PATCH
+func applyPostUpdate(c *gin.Context, body map[string]json.RawMessage) {
+	// Izin dicek oleh middleware.RequirePermission di routes
+	currentUserID, ok := currentUserID(c)
+	if !ok {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
 		return
 	}
-	var currentUserID uint
-	switch v := uidVal.(type) {
-	case uint:
-		currentUserID = v
-	case int:
-		currentUserID = uint(v)
-	default:
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+
+	postID, ok := parsePostID(c)
+	if !ok {
 		return
 	}
 
-	// Periksa apakah user adalah admin (jika Anda memiliki kolom IsAdmin di model User)
-	var user models.User
-	if err := config.DB.First(&user, currentUserID).Error; err != nil || !user.IsAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: Only administrators can update posts"})
+	updates, err := parsePostPatch(body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if len(updates) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "No fields to update"})
+		return
+	}
+	if imageURL, ok := updates["image_url"].(string); ok && imageURL != "" {
+		if err := validateImageURL(config.DB, imageURL); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid image_url: " + err.Error()})
+			return
+		}
+	}
 
-	id := c.Param("id")
-	var post models.Post
-	if err := config.DB.First(&post, id).Error; err != nil {
+	tx := config.DB.Begin()
+
+	post, err := lockPostForWrite(tx, postID)
+	if err != nil {
+		tx.Rollback()
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 			return
@@ -60,27 +158,75 @@ func UpdatePost(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post: " + err.Error()})
 		return
 	}
+	if !checkIfMatch(c, postETag(post)) {
+		tx.Rollback()
+		return
+	}
 
-	var input UpdatePostInput
-	if err := c.ShouldBindJSON(&input); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	// Map (bukan struct) agar string kosong tetap disimpan
+	updates["version"] = gorm.Expr("version + 1")
+	if err := tx.Model(&post).Updates(updates).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post: " + err.Error()})
+		return
+	}
+	var updated models.Post
+	if err := tx.Preload("Status").First(&updated, post.ID).Error; err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post after update: " + err.Error()})
+		return
+	}
+	if err := recordAudit(tx, c, auditEntry{Action: "post.update", EntityType: "post", EntityID: post.ID, Before: post, After: updated}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record audit log: " + err.Error()})
 		return
 	}
 
-	// Lakukan update pada post
-	config.DB.Model(&post).Updates(models.Post{
-		Title:      input.Title,
-		Keterangan: input.Keterangan,
-		Ruangan:    input.Ruangan,
-		ImageURL:   input.ImageURL, // Jika image_url bisa diupdate
-	})
+	fields := make([]string, 0, len(body))
+	for name := range body {
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
 
+	event := notificationEvent{
+		Type:   models.NotificationPostUpdated,
+		PostID: post.ID,
+		Payload: map[string]interface{}{
+			"post_id":    post.ID,
+			"title":      post.Title,
+			"fields":     fields,
+			"actor_id":   currentUserID,
+			"actor_name": usernameOf(tx, currentUserID),
+		},
+	}
+	if err := notifyAdmins(tx, event); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification for update: " + err.Error()})
+		return
+	}
+	tx.Commit()
+
+	// Ambil ulang agar respons berisi data terbaru beserta Status
+	if err := config.DB.Preload("Status").Preload("Author").First(&post, post.ID).Error; err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post after update: " + err.Error()})
+		return
+	}
+	// Perubahan isi bisa memunculkan kecocokan baru dengan laporan kehilangan
+	for _, column := range []string{"title", "keterangan", "ruangan", "item_type"} {
+		if _, changed := updates[column]; changed {
+			suggestMatchesForPost(config.DB, post)
+			break
+		}
+	}
+	attachPostImageVariants(config.DB, &post)
+
+	c.Header("ETag", postETag(post))
 	c.JSON(http.StatusOK, gin.H{"message": "Post updated successfully", "post": post})
 }
 
 // DeletePost handler: memerlukan AuthRequired middleware dan id post
 func DeletePost(c *gin.Context) {
-	// Pastikan user admin yang login
+	// Izin dicek oleh middleware.RequirePermission di routes
 	uidVal, exists := c.Get("userID")
 	if !exists {
 		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
@@ -97,16 +243,16 @@ func DeletePost(c *gin.Context) {
 		return
 	}
 
-	// Periksa apakah user adalah admin (jika Anda memiliki kolom IsAdmin di model User)
-	var user models.User
-	if err := config.DB.First(&user, currentUserID).Error; err != nil || !user.IsAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: Only administrators can delete posts"})
+	postID, ok := parsePostID(c)
+	if !ok {
 		return
 	}
 
-	id := c.Param("id")
-	var post models.Post
-	if err := config.DB.First(&post, id).Error; err != nil {
+	tx := config.DB.Begin()
+
+	post, err := lockPostForWrite(tx, postID)
+	if err != nil {
+		tx.Rollback()
 		if err == gorm.ErrRecordNotFound {
 			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 			return
@@ -114,15 +260,51 @@ func DeletePost(c *gin.Context) {
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post: " + err.Error()})
 		return
 	}
+	if !checkIfMatch(c, postETag(post)) {
+		tx.Rollback()
+		return
+	}
+
+	event := notificationEvent{
+		Type:   models.NotificationPostDeleted,
+		PostID: post.ID,
+		Payload: map[string]interface{}{
+			"post_id":    post.ID,
+			"title":      post.Title,
+			"actor_id":   currentUserID,
+			"actor_name": usernameOf(tx, currentUserID),
+		},
+	}
+	if err := notifyAdmins(tx, event); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification for deletion: " + err.Error()})
+		return
+	}
 
-	// Hapus post
-	// GORM akan otomatis menghapus Status dan Notifikasi karena OnDelete:CASCADE
-	if err := config.DB.Delete(&post).Error; err != nil {
+	// Soft delete: post masuk tempat sampah, Status dan notifikasi tetap utuh sampai di-purge
+	if err := tx.Model(&post).Updates(map[string]interface{}{
+		"deleted_at": time.Now(),
+		"deleted_by": currentUserID,
+		"version":    gorm.Expr("version + 1"),
+	}).Error; err != nil {
+		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to delete post: " + err.Error()})
 		return
 	}
+	if err := recordAudit(tx, c, auditEntry{
+		Action:     "post.delete",
+		EntityType: "post",
+		EntityID:   post.ID,
+		Before:     post,
+		After:      gin.H{"deleted_by": currentUserID},
+	}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record audit log: " + err.Error()})
+		return
+	}
+	tx.Commit()
 
-	c.JSON(http.StatusOK, gin.H{"message": "Post deleted successfully"})
+	c.JSON(http.StatusOK, gin.H{"message": "Post moved to trash", "retention_days": trashRetentionDays()})
 }
 
 // Input untuk menandai post sebagai selesai
@@ -149,16 +331,8 @@ func MarkPostAsDone(c *gin.Context) {
 		return
 	}
 
-	var user models.User
-	if err := config.DB.First(&user, currentUserID).Error; err != nil || !user.IsAdmin {
-		c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: Only administrators can mark posts as done"})
-		return
-	}
-
-	id := c.Param("id")
-	postID, err := strconv.ParseUint(id, 10, 32)
-	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid Post ID"})
+	postID, ok := parsePostID(c)
+	if !ok {
 		return
 	}
 
@@ -167,45 +341,64 @@ func MarkPostAsDone(c *gin.Context) {
 		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
+	if err := validateImageURL(config.DB, input.ProofImage); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid proof_image: " + err.Error()})
+		return
+	}
 
 	tx := config.DB.Begin() // Mulai transaksi
 
-	var status models.Status
-	if err := tx.Where("post_id = ?", postID).First(&status).Error; err != nil {
+	post, err := lockPostForWrite(tx, postID)
+	if err != nil {
 		tx.Rollback()
 		if err == gorm.ErrRecordNotFound {
-			c.JSON(http.StatusNotFound, gin.H{"error": "Status for this post not found"})
+			c.JSON(http.StatusNotFound, gin.H{"error": "Post not found"})
 			return
 		}
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve status: " + err.Error()})
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to retrieve post: " + err.Error()})
+		return
+	}
+	if !checkIfMatch(c, postETag(post)) {
+		tx.Rollback()
 		return
 	}
+	status := post.Status
+	statusBefore := post.Status
 
-	// Update status post
-	status.Status = 0 // Misalnya, 0 berarti Done, 1 berarti Active
+	// Update status post: barang diserahkan (returned)
 	status.ClaimerName = input.ClaimerName
 	status.ProofImage = input.ProofImage
-	status.UpdatedBy = currentUserID
-	status.UpdatedAt = time.Now() // Pastikan UpdatedAt diperbarui
-
-	if err := tx.Save(&status).Error; err != nil {
+	if err := transitionPost(tx, &status, models.StateReturned, currentUserID, "Diambil oleh "+input.ClaimerName); err != nil {
 		tx.Rollback()
+		var te *transitionError
+		if errors.As(err, &te) {
+			c.JSON(http.StatusConflict, gin.H{"error": te.Error()})
+			return
+		}
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to update post status: " + err.Error()})
 		return
 	}
 
-	// Buat notifikasi baru untuk penandaan selesai
-	var post models.Post
-	tx.First(&post, postID) // Ambil post untuk notifikasi
-
-	notificationMessage := fmt.Sprintf("Laporan '%s' telah diselesaikan oleh Admin", post.Title)
-	notification := models.Notification{
-		PostID:  uint(postID),
-		Message: notificationMessage,
-		IsRead:  false,
+	post.Status = status
+	if err := recordAudit(tx, c, auditEntry{Action: "post.done", EntityType: "post", EntityID: post.ID, Before: statusBefore, After: status}); err != nil {
+		tx.Rollback()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to record audit log: " + err.Error()})
+		return
 	}
 
-	if err := tx.Create(&notification).Error; err != nil {
+	// Buat notifikasi baru untuk penandaan selesai
+	event := notificationEvent{
+		Type:   models.NotificationPostDone,
+		PostID: post.ID,
+		Payload: map[string]interface{}{
+			"post_id":      post.ID,
+			"title":        post.Title,
+			"claimer_name": status.ClaimerName,
+			"actor_id":     currentUserID,
+			"actor_name":   usernameOf(tx, currentUserID),
+		},
+	}
+	if err := notifyAdmins(tx, event); err != nil {
 		tx.Rollback()
 		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create notification for completion: " + err.Error()})
 		return
@@ -213,5 +406,6 @@ func MarkPostAsDone(c *gin.Context) {
 
 	tx.Commit() // Commit transaksi
 
+	c.Header("ETag", postETag(post))
 	c.JSON(http.StatusOK, gin.H{"message": "Post marked as done successfully", "status": status})
 }
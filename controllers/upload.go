@@ -0,0 +1,260 @@
+package controllers
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"filoti-backend/config"
+	"filoti-backend/images"
+	"filoti-backend/models"
+
+	"github.com/gin-gonic/gin"
+	"gorm.io/gorm"
+)
+
+const defaultUploadMaxBytes = 5 << 20 // 5 MiB
+
+var errImageNotUploaded = errors.New("image URL must come from POST /uploads")
+
+// uploadMaxBytes membaca batas ukuran file dari UPLOAD_MAX_BYTES
+func uploadMaxBytes() int64 {
+	if v, err := strconv.ParseInt(os.Getenv("UPLOAD_MAX_BYTES"), 10, 64); err == nil && v > 0 {
+		return v
+	}
+	return defaultUploadMaxBytes
+}
+
+// UploadImage handler: POST /uploads (multipart, field "file").
+// Gambar divalidasi dari isinya, metadata EXIF/GPS dibuang dengan encode ulang,
+// lalu disimpan ke config.Storage. URL yang dikembalikan dipakai untuk image_url / proof_image.
+func UploadImage(c *gin.Context) {
+	userID, ok := currentUserID(c)
+	if !ok {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	maxBytes := uploadMaxBytes()
+	// Sisakan ruang untuk boundary dan header multipart
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes+64<<10)
+
+	fileHeader, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large", "max_bytes": maxBytes})
+			return
+		}
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Multipart field 'file' is required"})
+		return
+	}
+	if fileHeader.Size > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large", "max_bytes": maxBytes})
+		return
+	}
+
+	file, err := fileHeader.Open()
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxBytes+1))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "Failed to read uploaded file"})
+		return
+	}
+	if int64(len(data)) > maxBytes {
+		c.JSON(http.StatusRequestEntityTooLarge, gin.H{"error": "File too large", "max_bytes": maxBytes})
+		return
+	}
+
+	if !images.AllowedTypes[images.DetectType(data)] {
+		c.JSON(http.StatusUnsupportedMediaType, gin.H{"error": "Only JPEG, PNG and WebP images are allowed"})
+		return
+	}
+	sanitized, err := images.Sanitize(data)
+	if err != nil {
+		c.JSON(http.StatusUnprocessableEntity, gin.H{"error": "Invalid image: " + err.Error()})
+		return
+	}
+
+	baseKey, err := newUploadKey()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate file name"})
+		return
+	}
+	key := baseKey + sanitized.Extension
+
+	// Simpan original lalu setiap varian ukuran; jika salah satu gagal, hapus yang sudah tersimpan
+	ctx := c.Request.Context()
+	var storedKeys []string
+	cleanup := func() {
+		for _, k := range storedKeys {
+			config.Storage.Delete(ctx, k)
+		}
+	}
+	if err := config.Storage.Put(ctx, key, sanitized.Data, sanitized.ContentType); err != nil {
+		log.Printf("UploadImage: failed to store %s - %v", key, err)
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+		return
+	}
+	storedKeys = append(storedKeys, key)
+
+	variantURLs := make(map[string]string, len(images.Variants))
+	for _, v := range images.Variants {
+		data, err := images.EncodeVariant(sanitized.Image, v)
+		if err != nil {
+			cleanup()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate image variant: " + err.Error()})
+			return
+		}
+		variantKey := baseKey + "_" + v.Name + ".jpg"
+		if err := config.Storage.Put(ctx, variantKey, data, "image/jpeg"); err != nil {
+			log.Printf("UploadImage: failed to store %s - %v", variantKey, err)
+			cleanup()
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to store file"})
+			return
+		}
+		storedKeys = append(storedKeys, variantKey)
+		variantURLs[v.Name] = config.Storage.URL(variantKey)
+	}
+
+	bounds := sanitized.Image.Bounds()
+	upload := models.Upload{
+		Key:         key,
+		URL:         config.Storage.URL(key),
+		ContentType: sanitized.ContentType,
+		Size:        int64(len(sanitized.Data)),
+		Width:       bounds.Dx(),
+		Height:      bounds.Dy(),
+		UploadedBy:  userID,
+		Variants: models.ImageVariants{
+			Thumbnail: variantURLs["thumbnail"],
+			Medium:    variantURLs["medium"],
+			Full:      variantURLs["full"],
+		},
+	}
+	if err := config.DB.Create(&upload).Error; err != nil {
+		cleanup()
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to save upload: " + err.Error()})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{"message": "File uploaded successfully", "url": upload.URL, "upload": upload})
+}
+
+// newUploadKey membuat key acak (tanpa ekstensi) yang dikelompokkan per bulan, misalnya "images/2025/06/<hex>"
+func newUploadKey() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return "images/" + time.Now().Format("2006/01") + "/" + hex.EncodeToString(b), nil
+}
+
+// validateImageURL memastikan URL gambar berasal dari endpoint upload kita jika
+// ALLOW_EXTERNAL_IMAGE_URLS=false. Default-nya URL eksternal tetap diterima seperti sebelumnya (klien lama).
+func validateImageURL(db *gorm.DB, url string) error {
+	if url == "" || os.Getenv("ALLOW_EXTERNAL_IMAGE_URLS") != "false" {
+		return nil
+	}
+	var count int64
+	if err := db.Model(&models.Upload{}).Where("url = ?", url).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errImageNotUploaded
+	}
+	return nil
+}
+
+// deleteUnusedUploads menghapus file upload (original dan semua varian) beserta barisnya untuk
+// URL yang sudah tidak dipakai post, klaim, status maupun laporan kehilangan. Error hanya di-log.
+func deleteUnusedUploads(db *gorm.DB, urls []string) {
+	if len(urls) == 0 {
+		return
+	}
+	var uploads []models.Upload
+	err := db.Where("url IN ?", urls).
+		Where("NOT EXISTS (SELECT 1 FROM posts WHERE posts.image_url = uploads.url)").
+		Where("NOT EXISTS (SELECT 1 FROM claims WHERE claims.proof_image = uploads.url)").
+		Where("NOT EXISTS (SELECT 1 FROM statuses WHERE statuses.proof_image = uploads.url)").
+		Where("NOT EXISTS (SELECT 1 FROM lost_reports WHERE lost_reports.image_url = uploads.url)").
+		Find(&uploads).Error
+	if err != nil {
+		log.Printf("deleteUnusedUploads: failed to load uploads - %v", err)
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	for _, u := range uploads {
+		keys := []string{u.Key}
+		baseKey := strings.TrimSuffix(u.Key, path.Ext(u.Key))
+		for _, v := range images.Variants {
+			keys = append(keys, baseKey+"_"+v.Name+".jpg") // Sama seperti UploadImage
+		}
+		failed := false
+		for _, k := range keys {
+			if err := config.Storage.Delete(ctx, k); err != nil {
+				log.Printf("deleteUnusedUploads: failed to delete %s - %v", k, err)
+				failed = true
+			}
+		}
+		if failed {
+			continue // Baris dipertahankan agar purge berikutnya mencoba lagi
+		}
+		if err := db.Delete(&u).Error; err != nil {
+			log.Printf("deleteUnusedUploads: failed to delete upload %d - %v", u.ID, err)
+		}
+	}
+}
+
+// attachImageVariants mengisi ImageVariants untuk post yang gambarnya berasal dari POST /uploads
+func attachImageVariants(db *gorm.DB, posts []models.Post) {
+	urls := make([]string, 0, len(posts))
+	for _, p := range posts {
+		if p.ImageURL != "" {
+			urls = append(urls, p.ImageURL)
+		}
+	}
+	if len(urls) == 0 {
+		return
+	}
+
+	var uploads []models.Upload
+	if err := db.Where("url IN ?", urls).Find(&uploads).Error; err != nil {
+		log.Printf("attachImageVariants: failed to load uploads - %v", err)
+		return
+	}
+	byURL := make(map[string]models.ImageVariants, len(uploads))
+	for _, u := range uploads {
+		if u.Variants.Thumbnail != "" {
+			byURL[u.URL] = u.Variants
+		}
+	}
+	for i := range posts {
+		if v, ok := byURL[posts[i].ImageURL]; ok {
+			variants := v
+			posts[i].ImageVariants = &variants
+		}
+	}
+}
+
+// attachPostImageVariants versi attachImageVariants untuk satu post
+func attachPostImageVariants(db *gorm.DB, post *models.Post) {
+	posts := []models.Post{*post}
+	attachImageVariants(db, posts)
+	post.ImageVariants = posts[0].ImageVariants
+}
@@ -0,0 +1,87 @@
+// Package images memvalidasi dan membersihkan gambar yang di-upload user.
+package images
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"net/http"
+
+	_ "golang.org/x/image/webp" // registrasi decoder WebP untuk image.Decode
+)
+
+// ErrUnsupportedType dikembalikan untuk file yang bukan JPEG, PNG atau WebP
+var ErrUnsupportedType = errors.New("unsupported image type")
+
+// MaxPixels membatasi dimensi gambar agar decode tidak menghabiskan memori (decompression bomb).
+// Decode, orientasi dan varian masing-masing bisa menyalin gambar RGBA (4 byte per piksel),
+// jadi 16 MP sudah berarti beberapa ratus MB di puncaknya.
+const MaxPixels = 16_000_000
+
+// ErrTooLarge dikembalikan untuk gambar yang dimensinya melebihi MaxPixels
+var ErrTooLarge = errors.New("image dimensions too large")
+
+// AllowedTypes adalah MIME type yang diterima endpoint upload
+var AllowedTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/webp": true,
+}
+
+// Sanitized adalah hasil gambar yang sudah di-encode ulang tanpa metadata
+type Sanitized struct {
+	Data        []byte
+	ContentType string
+	Extension   string
+	Image       image.Image
+}
+
+// DetectType mengembalikan MIME type dari isi file (bukan dari header yang dikirim klien)
+func DetectType(data []byte) string {
+	return http.DetectContentType(data)
+}
+
+// Sanitize men-decode gambar, menerapkan orientasi EXIF, lalu meng-encode ulang
+// sehingga seluruh metadata (EXIF, GPS, komentar) terbuang.
+// PNG tetap PNG (menjaga transparansi); JPEG dan WebP menjadi JPEG.
+func Sanitize(data []byte) (*Sanitized, error) {
+	contentType := DetectType(data)
+	if !AllowedTypes[contentType] {
+		return nil, ErrUnsupportedType
+	}
+
+	// DecodeConfig hanya membaca header, jadi dimensi diperiksa sebelum buffer piksel dialokasikan
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || int64(cfg.Width)*int64(cfg.Height) > MaxPixels {
+		return nil, ErrTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if contentType == "image/jpeg" {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+
+	var buf bytes.Buffer
+	out := &Sanitized{Image: img}
+	if contentType == "image/png" {
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, err
+		}
+		out.ContentType, out.Extension = "image/png", ".png"
+	} else {
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 90}); err != nil {
+			return nil, err
+		}
+		out.ContentType, out.Extension = "image/jpeg", ".jpg"
+	}
+	out.Data = buf.Bytes()
+	return out, nil
+}
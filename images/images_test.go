@@ -0,0 +1,221 @@
+package images
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+)
+
+// exifSegment menyusun segmen APP1 Exif dengan tag Orientation dan data tambahan (pengganti GPS dsb.)
+func exifSegment(order binary.ByteOrder, orientation uint16, extra string) []byte {
+	var tiff bytes.Buffer
+	if order == binary.LittleEndian {
+		tiff.WriteString("II")
+	} else {
+		tiff.WriteString("MM")
+	}
+	binary.Write(&tiff, order, uint16(42))
+	binary.Write(&tiff, order, uint32(8)) // Offset IFD0
+	binary.Write(&tiff, order, uint16(1)) // Satu entry
+	binary.Write(&tiff, order, uint16(0x0112))
+	binary.Write(&tiff, order, uint16(3)) // SHORT
+	binary.Write(&tiff, order, uint32(1))
+	binary.Write(&tiff, order, orientation)
+	binary.Write(&tiff, order, uint16(0))
+	binary.Write(&tiff, order, uint32(0)) // Tidak ada IFD berikutnya
+	tiff.WriteString(extra)
+
+	payload := append([]byte("Exif\x00\x00"), tiff.Bytes()...)
+	seg := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(seg[2:], uint16(len(payload)+2))
+	return append(seg, payload...)
+}
+
+// withExif menyisipkan segmen APP1 tepat setelah SOI
+func withExif(jpg, seg []byte) []byte {
+	out := append([]byte{}, jpg[:2]...)
+	out = append(out, seg...)
+	return append(out, jpg[2:]...)
+}
+
+// halves membuat gambar w x h dengan separuh kiri merah dan separuh kanan biru
+func halves(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			c := color.RGBA{255, 0, 0, 255}
+			if x >= w/2 {
+				c = color.RGBA{0, 0, 255, 255}
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	plain := encodeJPEG(t, halves(8, 8))
+	tests := []struct {
+		name string
+		data []byte
+		want int
+	}{
+		{"no exif", plain, 1},
+		{"little endian", withExif(plain, exifSegment(binary.LittleEndian, 6, "")), 6},
+		{"big endian", withExif(plain, exifSegment(binary.BigEndian, 8, "")), 8},
+		{"out of range", withExif(plain, exifSegment(binary.LittleEndian, 9, "")), 1},
+		{"truncated segment", withExif(plain, exifSegment(binary.LittleEndian, 6, ""))[:12], 1},
+		{"not a jpeg", []byte("\x89PNG\r\n\x1a\n"), 1},
+		{"empty", nil, 1},
+	}
+	for _, tt := range tests {
+		if got := jpegOrientation(tt.data); got != tt.want {
+			t.Errorf("%s: jpegOrientation = %d, want %d", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestApplyOrientation(t *testing.T) {
+	// Gambar 2x3 dengan piksel bernomor baris demi baris:
+	//   0 1
+	//   2 3
+	//   4 5
+	src := image.NewRGBA(image.Rect(0, 0, 2, 3))
+	for i := 0; i < 6; i++ {
+		src.SetRGBA(i%2, i/2, color.RGBA{uint8(i * 40), 0, 0, 255})
+	}
+	tests := []struct {
+		orientation int
+		want        [][]int // Baris demi baris hasilnya
+	}{
+		{1, [][]int{{0, 1}, {2, 3}, {4, 5}}},
+		{2, [][]int{{1, 0}, {3, 2}, {5, 4}}}, // Cermin horizontal
+		{3, [][]int{{5, 4}, {3, 2}, {1, 0}}}, // Putar 180
+		{4, [][]int{{4, 5}, {2, 3}, {0, 1}}}, // Cermin vertikal
+		{5, [][]int{{0, 2, 4}, {1, 3, 5}}},   // Transpose
+		{6, [][]int{{4, 2, 0}, {5, 3, 1}}},   // Putar 90 searah jarum jam
+		{7, [][]int{{5, 3, 1}, {4, 2, 0}}},   // Transverse
+		{8, [][]int{{1, 3, 5}, {0, 2, 4}}},   // Putar 90 berlawanan jarum jam
+	}
+	for _, tt := range tests {
+		got := applyOrientation(src, tt.orientation)
+		b := got.Bounds()
+		if b.Dy() != len(tt.want) || b.Dx() != len(tt.want[0]) {
+			t.Errorf("orientation %d: size %dx%d, want %dx%d", tt.orientation, b.Dx(), b.Dy(), len(tt.want[0]), len(tt.want))
+			continue
+		}
+		for y, row := range tt.want {
+			for x, want := range row {
+				r, _, _, _ := got.At(b.Min.X+x, b.Min.Y+y).RGBA()
+				if int(r>>8) != want*40 {
+					t.Errorf("orientation %d: pixel (%d,%d) = %d, want %d", tt.orientation, x, y, r>>8/40, want)
+				}
+			}
+		}
+	}
+}
+
+func TestSanitizeStripsExifAndAppliesOrientation(t *testing.T) {
+	const secret = "GPS -6.2088,106.8456 SECRET"
+	data := withExif(encodeJPEG(t, halves(32, 16)), exifSegment(binary.LittleEndian, 6, secret))
+	if !bytes.Contains(data, []byte(secret)) {
+		t.Fatal("test image does not contain the EXIF payload")
+	}
+
+	out, err := Sanitize(data)
+	if err != nil {
+		t.Fatalf("Sanitize: %v", err)
+	}
+	if out.ContentType != "image/jpeg" || out.Extension != ".jpg" {
+		t.Errorf("type = %s %s, want image/jpeg .jpg", out.ContentType, out.Extension)
+	}
+	for _, leak := range []string{"Exif", secret} {
+		if bytes.Contains(out.Data, []byte(leak)) {
+			t.Errorf("sanitized image still contains %q", leak)
+		}
+	}
+	if jpegOrientation(out.Data) != 1 {
+		t.Error("sanitized image still has an orientation tag")
+	}
+
+	// Orientation 6: 32x16 diputar menjadi 16x32, separuh kiri (merah) menjadi separuh atas
+	decoded, err := jpeg.Decode(bytes.NewReader(out.Data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if b := decoded.Bounds(); b.Dx() != 16 || b.Dy() != 32 {
+		t.Fatalf("size = %dx%d, want 16x32", b.Dx(), b.Dy())
+	}
+	if r, _, bl, _ := decoded.At(8, 4).RGBA(); r>>8 < 200 || bl>>8 > 60 {
+		t.Errorf("top half is not red: r=%d b=%d", r>>8, bl>>8)
+	}
+	if r, _, bl, _ := decoded.At(8, 28).RGBA(); bl>>8 < 200 || r>>8 > 60 {
+		t.Errorf("bottom half is not blue: r=%d b=%d", r>>8, bl>>8)
+	}
+}
+
+func TestSanitizeKeepsPNG(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, halves(4, 4)); err != nil {
+		t.Fatal(err)
+	}
+	out, err := Sanitize(buf.Bytes())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out.ContentType != "image/png" || out.Extension != ".png" {
+		t.Errorf("type = %s %s, want image/png .png", out.ContentType, out.Extension)
+	}
+}
+
+// pngWithSize membuat PNG kecil lalu mengganti dimensi di header IHDR (CRC dihitung ulang),
+// sehingga DecodeConfig melihat gambar besar tanpa data piksel sebesar itu
+func pngWithSize(t *testing.T, w, h uint32) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewGray(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	data := buf.Bytes()
+	// Signature 8 byte, lalu panjang (4) dan tipe "IHDR" (4); lebar dan tinggi di offset 16 dan 20
+	binary.BigEndian.PutUint32(data[16:], w)
+	binary.BigEndian.PutUint32(data[20:], h)
+	binary.BigEndian.PutUint32(data[29:], crc32.ChecksumIEEE(data[12:29]))
+	return data
+}
+
+func TestSanitizeRejectsTooManyPixels(t *testing.T) {
+	tests := []struct {
+		name string
+		w, h uint32
+	}{
+		{"just over the limit", 4001, 4000},
+		{"decompression bomb", 50000, 50000},
+		{"overflows int32 multiplication", 65535, 65535},
+	}
+	for _, tt := range tests {
+		if _, err := Sanitize(pngWithSize(t, tt.w, tt.h)); err != ErrTooLarge {
+			t.Errorf("%s (%dx%d): Sanitize error = %v, want ErrTooLarge", tt.name, tt.w, tt.h, err)
+		}
+	}
+}
+
+func TestSanitizeRejectsUnsupportedType(t *testing.T) {
+	if _, err := Sanitize([]byte("GIF89a\x01\x00\x01\x00")); err != ErrUnsupportedType {
+		t.Errorf("Sanitize(gif) error = %v, want ErrUnsupportedType", err)
+	}
+}
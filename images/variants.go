@@ -0,0 +1,68 @@
+package images
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/jpeg"
+
+	"golang.org/x/image/draw"
+)
+
+// Variant adalah ukuran turunan yang dibuat untuk setiap gambar upload
+type Variant struct {
+	Name    string
+	MaxSide int // Sisi terpanjang dalam piksel
+}
+
+// Variants dipakai feed (thumbnail), halaman detail (medium) dan tampilan penuh (full)
+var Variants = []Variant{
+	{Name: "thumbnail", MaxSide: 320},
+	{Name: "medium", MaxSide: 800},
+	{Name: "full", MaxSide: 1600},
+}
+
+// Resize memperkecil gambar agar sisi terpanjangnya tidak melebihi maxSide.
+// Gambar yang sudah lebih kecil tidak diperbesar.
+func Resize(src image.Image, maxSide int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return src
+	}
+	if w >= h {
+		h = h * maxSide / w
+		w = maxSide
+	} else {
+		w = w * maxSide / h
+		h = maxSide
+	}
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, b, draw.Src, nil)
+	return dst
+}
+
+// EncodeVariant membuat JPEG untuk satu varian. Transparansi (PNG) diratakan ke latar putih.
+func EncodeVariant(src image.Image, v Variant) ([]byte, error) {
+	resized := Resize(src, v.MaxSide)
+	b := resized.Bounds()
+	flat := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(flat, flat.Bounds(), &image.Uniform{C: color.White}, image.Point{}, draw.Src)
+	draw.Draw(flat, flat.Bounds(), resized, b.Min, draw.Over)
+
+	quality := 82
+	if v.MaxSide <= 320 {
+		quality = 75
+	}
+	var buf bytes.Buffer
+	if err := jpeg.Encode(&buf, flat, &jpeg.Options{Quality: quality}); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,61 @@
+package mailer
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"time"
+)
+
+// File menulis setiap email sebagai file .eml, berguna untuk development dan pengujian manual
+type File struct {
+	Dir  string
+	from string
+}
+
+func NewFile(dir, from string) *File {
+	return &File{Dir: dir, from: from}
+}
+
+func (f *File) Send(_ context.Context, msg Message) error {
+	data, err := render(f.from, msg)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(f.Dir, 0o700); err != nil {
+		return err
+	}
+	// Isi email memuat token rahasia, jadi file hanya bisa dibaca pemilik proses
+	name := fmt.Sprintf("%s-%d.eml", time.Now().UTC().Format("20060102T150405"), time.Now().UnixNano()%1e9)
+	return os.WriteFile(filepath.Join(f.Dir, name), data, 0o600)
+}
+
+// Log hanya menulis email ke log server; driver default jika MAIL_DRIVER tidak diset.
+// Token di link (reset password, verifikasi email) disamarkan karena siapa pun yang bisa
+// membaca log server bisa memakainya untuk mengambil alih akun.
+type Log struct {
+	from string
+}
+
+func NewLog(from string) *Log {
+	return &Log{from: from}
+}
+
+func (l *Log) Send(_ context.Context, msg Message) error {
+	data, err := render(l.from, msg)
+	if err != nil {
+		return err
+	}
+	log.Printf("mailer: email not sent (MAIL_DRIVER=log)\n%s", redactTokens(data))
+	return nil
+}
+
+var tokenParam = regexp.MustCompile(`([?&]token=)[^&\s]+`)
+
+// redactTokens mengganti nilai parameter token pada URL di isi email
+func redactTokens(data []byte) []byte {
+	return tokenParam.ReplaceAll(data, []byte("${1}REDACTED"))
+}
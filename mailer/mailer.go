@@ -0,0 +1,98 @@
+// Package mailer mengirim email transaksional (reset password, verifikasi email) di balik
+// satu interface, dengan implementasi SMTP serta file/log untuk development.
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net/mail"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Message adalah email teks biasa ke satu penerima
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer adalah backend pengiriman email
+type Mailer interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// FromEnv membuat Mailer berdasarkan MAIL_DRIVER ("log", "file" atau "smtp").
+// Untuk MailHog lokal: MAIL_DRIVER=smtp, SMTP_HOST=localhost, SMTP_PORT=1025.
+// Di Vercel MAIL_DRIVER wajib diisi: default "log" tidak mengirim apa pun ke user.
+func FromEnv() (Mailer, error) {
+	from := os.Getenv("MAIL_FROM")
+	if from == "" {
+		from = "FILOTI <no-reply@filoti.local>"
+	}
+	if _, err := mail.ParseAddress(from); err != nil {
+		return nil, fmt.Errorf("invalid MAIL_FROM %q: %w", from, err)
+	}
+
+	driver := strings.ToLower(os.Getenv("MAIL_DRIVER"))
+	if driver == "" && os.Getenv("VERCEL") != "" {
+		return nil, fmt.Errorf("MAIL_DRIVER must be set explicitly on Vercel")
+	}
+	switch driver {
+	case "", "log":
+		return NewLog(from), nil
+	case "file":
+		dir := os.Getenv("MAIL_FILE_DIR")
+		if dir == "" {
+			dir = "mail"
+		}
+		return NewFile(dir, from), nil
+	case "smtp":
+		cfg := SMTPConfig{
+			Host:     os.Getenv("SMTP_HOST"),
+			Username: os.Getenv("SMTP_USERNAME"),
+			Password: os.Getenv("SMTP_PASSWORD"),
+			From:     from,
+		}
+		if cfg.Host == "" {
+			return nil, fmt.Errorf("SMTP_HOST must be set for the smtp mail driver")
+		}
+		cfg.Port = 587
+		if v := os.Getenv("SMTP_PORT"); v != "" {
+			port, err := strconv.Atoi(v)
+			if err != nil || port <= 0 {
+				return nil, fmt.Errorf("invalid SMTP_PORT %q", v)
+			}
+			cfg.Port = port
+		}
+		return NewSMTP(cfg), nil
+	}
+	return nil, fmt.Errorf("unknown MAIL_DRIVER %q", driver)
+}
+
+// render menyusun pesan RFC 5322 lengkap dengan header
+func render(from string, msg Message) ([]byte, error) {
+	if _, err := mail.ParseAddress(msg.To); err != nil {
+		return nil, fmt.Errorf("mailer: invalid recipient %q: %w", msg.To, err)
+	}
+	// Cegah header injection lewat subject
+	if strings.ContainsAny(msg.Subject, "\r\n") {
+		return nil, fmt.Errorf("mailer: subject must not contain line breaks")
+	}
+
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	fmt.Fprintf(&buf, "To: %s\r\n", msg.To)
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", msg.Subject))
+	fmt.Fprintf(&buf, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(strings.ReplaceAll(msg.Body, "\r\n", "\n"), "\n", "\r\n"))
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,84 @@
+package mailer
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRedactTokens(t *testing.T) {
+	body := "Klik link berikut:\r\nhttps://filoti.app/reset-password?token=abc_DEF-123\r\n" +
+		"https://filoti.app/verify-email?lang=id&token=xyz%2F9 lalu selesai\r\n"
+	got := string(redactTokens([]byte(body)))
+	for _, secret := range []string{"abc_DEF-123", "xyz%2F9"} {
+		if strings.Contains(got, secret) {
+			t.Errorf("token %q not redacted:\n%s", secret, got)
+		}
+	}
+	for _, keep := range []string{"?token=REDACTED\r\n", "&token=REDACTED lalu selesai", "lang=id"} {
+		if !strings.Contains(got, keep) {
+			t.Errorf("redacted body missing %q:\n%s", keep, got)
+		}
+	}
+}
+
+func TestFromEnvRequiresDriverOnVercel(t *testing.T) {
+	t.Setenv("VERCEL", "1")
+	t.Setenv("MAIL_DRIVER", "")
+	if _, err := FromEnv(); err == nil {
+		t.Fatal("FromEnv accepted the default log driver on Vercel")
+	}
+	t.Setenv("MAIL_DRIVER", "log")
+	if _, err := FromEnv(); err != nil {
+		t.Fatalf("explicit MAIL_DRIVER=log: %v", err)
+	}
+}
+
+func TestRenderRejectsHeaderInjection(t *testing.T) {
+	tests := []struct {
+		name string
+		msg  Message
+	}{
+		{"CRLF in subject", Message{To: "budi@example.com", Subject: "Halo\r\nBcc: evil@example.com"}},
+		{"LF in subject", Message{To: "budi@example.com", Subject: "Halo\nBcc: evil@example.com"}},
+		{"CR in subject", Message{To: "budi@example.com", Subject: "Halo\rBcc: evil@example.com"}},
+		{"CRLF after recipient", Message{To: "budi@example.com\r\nBcc: evil@example.com", Subject: "Halo"}},
+		{"CRLF in display name", Message{To: "\"Budi\r\nBcc: evil@example.com\" <budi@example.com>", Subject: "Halo"}},
+		{"two recipients", Message{To: "budi@example.com, evil@example.com", Subject: "Halo"}},
+		{"not an address", Message{To: "budi", Subject: "Halo"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if data, err := render("noreply@filoti.test", tt.msg); err == nil {
+				t.Errorf("render accepted %q / %q:\n%s", tt.msg.To, tt.msg.Subject, data)
+			}
+		})
+	}
+}
+
+func TestRenderHeadersAndBody(t *testing.T) {
+	data, err := render("Filoti <noreply@filoti.test>", Message{
+		To:      "budi@example.com",
+		Subject: "Barang ditemukan ✓",
+		Body:    "baris 1\nbaris 2\r\nbaris 3",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, body, ok := strings.Cut(string(data), "\r\n\r\n")
+	if !ok {
+		t.Fatalf("no header/body separator:\n%s", data)
+	}
+	if body != "baris 1\r\nbaris 2\r\nbaris 3" {
+		t.Errorf("body = %q, want CRLF line endings", body)
+	}
+	for _, want := range []string{"From: Filoti <noreply@filoti.test>", "To: budi@example.com", "Subject: =?utf-8?q?", "MIME-Version: 1.0"} {
+		if !strings.Contains(header, want) {
+			t.Errorf("header missing %q:\n%s", want, header)
+		}
+	}
+	for _, line := range strings.Split(header, "\r\n") {
+		if strings.ContainsAny(line, "\r\n") {
+			t.Errorf("header line contains a bare line break: %q", line)
+		}
+	}
+}
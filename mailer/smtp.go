@@ -0,0 +1,103 @@
+package mailer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"time"
+)
+
+// SMTPConfig berisi konfigurasi server SMTP. Username kosong berarti tanpa AUTH (mis. MailHog).
+type SMTPConfig struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// SMTP mengirim email lewat server SMTP; STARTTLS dipakai otomatis jika server mendukungnya
+type SMTP struct {
+	cfg SMTPConfig
+}
+
+func NewSMTP(cfg SMTPConfig) *SMTP {
+	return &SMTP{cfg: cfg}
+}
+
+func (s *SMTP) Send(ctx context.Context, msg Message) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	data, err := render(s.cfg.From, msg)
+	if err != nil {
+		return err
+	}
+	from, _ := mail.ParseAddress(s.cfg.From) // Sudah divalidasi di FromEnv
+	to, _ := mail.ParseAddress(msg.To)       // Sudah divalidasi di render
+
+	if err := s.send(ctx, from.Address, to.Address, data); err != nil {
+		return fmt.Errorf("mailer: smtp send to %s: %w", to.Address, err)
+	}
+	return nil
+}
+
+// send menjalankan percakapan SMTP seperti smtp.SendMail, tetapi koneksinya mengikuti ctx:
+// dial memakai DialContext dan deadline koneksi diambil dari ctx sehingga server yang
+// macet tidak menahan request lebih lama dari timeout pemanggil
+func (s *SMTP) send(ctx context.Context, from, to string, data []byte) error {
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port)))
+	if err != nil {
+		return err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	// Pembatalan ctx sebelum deadline juga menghentikan I/O yang sedang berjalan
+	stop := context.AfterFunc(ctx, func() { conn.SetDeadline(time.Now()) })
+	defer stop()
+
+	c, err := smtp.NewClient(conn, s.cfg.Host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(&tls.Config{ServerName: s.cfg.Host}); err != nil {
+			return err
+		}
+	}
+	if s.cfg.Username != "" {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return errors.New("server doesn't support AUTH")
+		}
+		if err := c.Auth(smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	if err := c.Rcpt(to); err != nil {
+		return err
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(data); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return c.Quit()
+}
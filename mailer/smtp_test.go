@@ -0,0 +1,147 @@
+package mailer
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net"
+	"strings"
+	"testing"
+	"time"
+)
+
+// received adalah apa yang diterima testSMTPServer dalam satu percakapan
+type received struct {
+	from, to string
+	data     string
+}
+
+// testSMTPServer menjalankan server SMTP minimal (tanpa STARTTLS/AUTH) di 127.0.0.1.
+// Jika greet false, koneksi diterima tetapi server tidak pernah membalas (server macet).
+func testSMTPServer(t *testing.T, greet bool) (SMTPConfig, <-chan received) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+
+	out := make(chan received, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		if !greet {
+			io.Copy(io.Discard, conn) // Diam sampai klien menutup koneksi
+			return
+		}
+		r := bufio.NewReader(conn)
+		reply := func(line string) { conn.Write([]byte(line + "\r\n")) }
+		var got received
+		reply("220 localhost ESMTP test")
+		for {
+			line, err := r.ReadString('\n')
+			if err != nil {
+				return
+			}
+			cmd := strings.TrimRight(line, "\r\n")
+			switch upper := strings.ToUpper(cmd); {
+			case strings.HasPrefix(upper, "EHLO"):
+				reply("250-localhost")
+				reply("250 8BITMIME")
+			case strings.HasPrefix(upper, "MAIL FROM:"):
+				got.from = strings.Trim(strings.Fields(cmd[len("MAIL FROM:"):])[0], "<>")
+				reply("250 OK")
+			case strings.HasPrefix(upper, "RCPT TO:"):
+				got.to = strings.Trim(cmd[len("RCPT TO:"):], "<> ")
+				reply("250 OK")
+			case upper == "DATA":
+				reply("354 End data with <CR><LF>.<CR><LF>")
+				var data strings.Builder
+				for {
+					l, err := r.ReadString('\n')
+					if err != nil {
+						return
+					}
+					if l == ".\r\n" {
+						break
+					}
+					data.WriteString(l)
+				}
+				got.data = data.String()
+				reply("250 OK")
+			case upper == "QUIT":
+				reply("221 Bye")
+				out <- got
+				return
+			default:
+				reply("502 Command not implemented")
+			}
+		}
+	}()
+
+	addr := ln.Addr().(*net.TCPAddr)
+	return SMTPConfig{Host: "127.0.0.1", Port: addr.Port, From: "Filoti <noreply@filoti.test>"}, out
+}
+
+func TestSMTPSend(t *testing.T) {
+	cfg, out := testSMTPServer(t, true)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := NewSMTP(cfg).Send(ctx, Message{
+		To:      "Budi <budi@example.com>",
+		Subject: "Reset password",
+		Body:    "Halo\nklik link ini",
+	})
+	if err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	got := <-out
+	if got.from != "noreply@filoti.test" || got.to != "budi@example.com" {
+		t.Errorf("envelope = %s -> %s, want noreply@filoti.test -> budi@example.com", got.from, got.to)
+	}
+	for _, want := range []string{"From: Filoti <noreply@filoti.test>\r\n", "To: Budi <budi@example.com>\r\n", "\r\n\r\nHalo\r\nklik link ini"} {
+		if !strings.Contains(got.data, want) {
+			t.Errorf("data missing %q:\n%s", want, got.data)
+		}
+	}
+}
+
+func TestSMTPSendHonoursContextDeadline(t *testing.T) {
+	cfg, _ := testSMTPServer(t, false)
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := NewSMTP(cfg).Send(ctx, Message{To: "budi@example.com", Subject: "x", Body: "x"})
+	if err == nil {
+		t.Fatal("Send to a stalled server succeeded")
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("Send returned after %v, want it bounded by the 200ms deadline", elapsed)
+	}
+}
+
+func TestSMTPSendRequiresAuthSupport(t *testing.T) {
+	cfg, _ := testSMTPServer(t, true)
+	cfg.Username, cfg.Password = "user", "secret"
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	err := NewSMTP(cfg).Send(ctx, Message{To: "budi@example.com", Subject: "x", Body: "x"})
+	if err == nil || !strings.Contains(err.Error(), "AUTH") {
+		t.Errorf("Send without server AUTH = %v, want AUTH error", err)
+	}
+}
+
+func TestSMTPSendCancelledContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	s := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: 1, From: "noreply@filoti.test"})
+	if err := s.Send(ctx, Message{To: "budi@example.com"}); err != context.Canceled {
+		t.Errorf("Send with cancelled ctx = %v, want context.Canceled", err)
+	}
+}
@@ -4,8 +4,8 @@ import (
 	"log"
 	"net/http" // Required for http.ResponseWriter, http.Request, and http.ListenAndServe for local testing
 	"os"
+	"time"
 
-	// "time" // Not directly used in main.go now, can be removed if not needed elsewhere
 	// "github.com/gin-contrib/cors"            // Not needed here anymore as CORS is in routes.go
 	// "github.com/gin-contrib/sessions"        // Not needed here anymore as sessions is in routes.go
 	// "github.com/gin-contrib/sessions/cookie" // Not needed here anymore
@@ -14,6 +14,7 @@ import (
 	"github.com/joho/godotenv"
 
 	"filoti-backend/config"
+	"filoti-backend/controllers"
 	"filoti-backend/routes"
 )
 
@@ -31,6 +32,11 @@ func init() {
 
 	// Initialize DB (will stay alive as long as Lambda container is warm)
 	config.InitDB()
+	config.InitStorage()
+	config.InitTokens()
+	config.InitMailer()
+	config.InitRateLimiter()
+	config.InitOIDC()
 
 	// Initialize Gin router and all its middleware/routes inside SetupRouter
 	// This is the router instance that will handle all requests
@@ -53,6 +59,9 @@ func main() {
 	if port == "" {
 		port = "8080"
 	}
+	// Di Vercel purge dijalankan oleh cron (vercel.json); secara lokal cukup goroutine berkala
+	go controllers.RunTrashPurger(24 * time.Hour)
+
 	log.Printf("Server running on port %s (for local development)", port)
 	// Use http.ListenAndServe with the global Gin router
 	if err := http.ListenAndServe(":"+port, r); err != nil {
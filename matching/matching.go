@@ -0,0 +1,127 @@
+// Package matching memberi skor kecocokan antara barang temuan (Post) dan laporan kehilangan.
+// Skor menggabungkan kemiripan teks, lokasi, kategori dan kedekatan waktu.
+package matching
+
+import (
+	"math"
+	"strings"
+	"time"
+)
+
+// Bobot masing-masing komponen skor (jumlahnya 1)
+const (
+	weightText     = 0.45
+	weightLocation = 0.20
+	weightCategory = 0.20
+	weightTime     = 0.15
+)
+
+// MinScore adalah skor minimum agar kandidat ditampilkan sebagai saran
+const MinScore = 0.30
+
+// HighConfidence adalah skor minimum untuk mengirim notifikasi otomatis ke pelapor
+const HighConfidence = 0.70
+
+// Found adalah data barang temuan yang dibandingkan
+type Found struct {
+	Title       string
+	Description string
+	Location    string
+	Category    string
+	FoundAt     time.Time
+}
+
+// Lost adalah data laporan kehilangan yang dibandingkan
+type Lost struct {
+	Title       string
+	Description string
+	Location    string
+	Category    string
+	LostFrom    time.Time
+	LostUntil   time.Time
+}
+
+// Score adalah skor total beserta rinciannya (semua 0..1)
+type Score struct {
+	Total    float64 `json:"total"`
+	Text     float64 `json:"text"`
+	Location float64 `json:"location"`
+	Category float64 `json:"category"`
+	Time     float64 `json:"time"`
+}
+
+// Compare menghitung skor kecocokan barang temuan f terhadap laporan kehilangan l
+func Compare(f Found, l Lost) Score {
+	s := Score{
+		Text:     textScore(f, l),
+		Location: locationScore(f.Location, l.Location),
+		Category: categoryScore(f.Category, l.Category),
+		Time:     timeScore(f.FoundAt, l.LostFrom, l.LostUntil),
+	}
+	s.Total = weightText*s.Text + weightLocation*s.Location + weightCategory*s.Category + weightTime*s.Time
+	s.Total = math.Round(s.Total*1000) / 1000
+	return s
+}
+
+// textScore memberi bobot lebih pada judul; deskripsi menambah konteks (warna, merek, isi)
+func textScore(f Found, l Lost) float64 {
+	fTitle, lTitle := Tokens(f.Title), Tokens(l.Title)
+	fAll := union(fTitle, Tokens(f.Description))
+	lAll := union(lTitle, Tokens(l.Description))
+	return 0.6*dice(fTitle, lTitle) + 0.4*dice(fAll, lAll)
+}
+
+// locationScore: ruangan sama = 1; gedung yang sama (kata setelah "gedung", misalnya "Gedung B") minimal 0.7
+// dan gedung yang berbeda = 0; selain itu kemiripan kata setelah membuang kata umum seperti "gedung" dan "lantai"
+func locationScore(a, b string) float64 {
+	na, nb := normalize(a), normalize(b)
+	if na == "" || nb == "" {
+		return 0
+	}
+	if na == nb {
+		return 1
+	}
+	score := dice(locationTokens(na), locationTokens(nb))
+	if ba, bb := building(na), building(nb); ba != "" && bb != "" {
+		if ba != bb {
+			return 0 // "lantai 2 gedung a" dan "lantai 2 gedung b" adalah tempat yang berbeda
+		}
+		score = math.Max(score, 0.7)
+	}
+	return score
+}
+
+func categoryScore(a, b string) float64 {
+	na, nb := normalize(a), normalize(b)
+	if na == "" || nb == "" {
+		return 0
+	}
+	if na == nb {
+		return 1
+	}
+	return 0
+}
+
+// timeScore: ditemukan di dalam rentang hilang = 1, setelahnya meluruh per minggu,
+// dan ditemukan jauh sebelum hilang = 0 (tidak mungkin barang yang sama)
+func timeScore(foundAt, lostFrom, lostUntil time.Time) float64 {
+	const slack = 12 * time.Hour
+	if foundAt.IsZero() || lostFrom.IsZero() {
+		return 0
+	}
+	if lostUntil.Before(lostFrom) {
+		lostUntil = lostFrom
+	}
+	switch {
+	case foundAt.Before(lostFrom.Add(-slack)):
+		return 0
+	case !foundAt.After(lostUntil.Add(slack)):
+		return 1
+	}
+	days := foundAt.Sub(lostUntil).Hours() / 24
+	return math.Exp(-days / 7)
+}
+
+func normalize(s string) string {
+	return strings.Join(strings.Fields(strings.ToLower(s)), " ")
+}
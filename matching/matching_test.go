@@ -0,0 +1,189 @@
+package matching
+
+import (
+	"math"
+	"sort"
+	"testing"
+	"time"
+)
+
+func set(words ...string) map[string]struct{} {
+	s := make(map[string]struct{}, len(words))
+	for _, w := range words {
+		s[w] = struct{}{}
+	}
+	return s
+}
+
+func keys(s map[string]struct{}) []string {
+	out := make([]string, 0, len(s))
+	for k := range s {
+		out = append(out, k)
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestTokens(t *testing.T) {
+	tests := []struct {
+		in   string
+		want []string
+	}{
+		{"", []string{}},
+		{"Dompet Kulit Hitam", []string{"dompet", "hitam", "kulit"}},
+		{"dompetnya hilang", []string{"dompet", "hilang"}},
+		{"punya", []string{"punya"}}, // Terlalu pendek untuk dibuang akhiran -nya
+		{"HP Samsung, warna biru!", []string{"biru", "hp", "samsung"}},
+		{"the black wallet with a key", []string{"black", "key", "wallet"}},
+		{"kunci x motor", []string{"kunci", "motor"}}, // Kata satu huruf dibuang
+		{"botol 500ml", []string{"500ml", "botol"}},
+		{"Tumbler/termos", []string{"termos", "tumbler"}},
+	}
+	for _, tt := range tests {
+		got := keys(Tokens(tt.in))
+		if len(got) != len(tt.want) {
+			t.Errorf("Tokens(%q) = %v, want %v", tt.in, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("Tokens(%q) = %v, want %v", tt.in, got, tt.want)
+				break
+			}
+		}
+	}
+}
+
+func TestContainsAll(t *testing.T) {
+	tests := []struct {
+		name       string
+		have, want map[string]struct{}
+		ok         bool
+	}{
+		{"empty want", set("dompet"), set(), true},
+		{"both empty", set(), set(), true},
+		{"subset", set("dompet", "hitam", "kulit"), set("dompet", "hitam"), true},
+		{"equal", set("dompet"), set("dompet"), true},
+		{"missing one", set("dompet", "hitam"), set("dompet", "coklat"), false},
+		{"empty have", set(), set("dompet"), false},
+	}
+	for _, tt := range tests {
+		if got := ContainsAll(tt.have, tt.want); got != tt.ok {
+			t.Errorf("%s: ContainsAll = %v, want %v", tt.name, got, tt.ok)
+		}
+	}
+}
+
+func TestLocationScore(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want float64
+	}{
+		{"", "Gedung B", 0},
+		{"Gedung B Lantai 2", "gedung  b lantai 2", 1},
+		{"Gedung B lantai 2", "Gedung B ruang 301", 0.7},
+		{"lantai 2 gedung a", "lantai 2 gedung b", 0},
+		{"Gedung A", "Gedung B", 0},
+		{"Gedung B", "lantai 3, Gedung B", 0.7},
+		{"lantai 2 perpustakaan", "lantai 2 kantin", 0.5}, // Hanya "2" yang sama, tanpa bonus gedung
+		{"lantai 2", "lantai 3", 0},
+		{"Kantin", "Perpustakaan", 0},
+		{"Ruang 301", "ruang 301 dekat pintu", 0.667},
+	}
+	for _, tt := range tests {
+		got := math.Round(locationScore(tt.a, tt.b)*1000) / 1000
+		if got != tt.want {
+			t.Errorf("locationScore(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+		if rev := math.Round(locationScore(tt.b, tt.a)*1000) / 1000; rev != got {
+			t.Errorf("locationScore is not symmetric for %q, %q: %v vs %v", tt.a, tt.b, got, rev)
+		}
+	}
+}
+
+func TestTimeScore(t *testing.T) {
+	from := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	until := from.Add(10 * time.Hour)
+	tests := []struct {
+		name    string
+		foundAt time.Time
+		from    time.Time
+		until   time.Time
+		want    float64
+	}{
+		{"unknown found time", time.Time{}, from, until, 0},
+		{"unknown lost time", from, time.Time{}, until, 0},
+		{"inside range", from.Add(time.Hour), from, until, 1},
+		{"slightly before lost (slack)", from.Add(-6 * time.Hour), from, until, 1},
+		{"long before lost", from.Add(-48 * time.Hour), from, until, 0},
+		{"within slack after", until.Add(12 * time.Hour), from, until, 1},
+		{"one week after", until.Add(7 * 24 * time.Hour), from, until, math.Exp(-1)},
+		{"until before from treated as from", from.Add(time.Hour), from, from.Add(-time.Hour), 1},
+	}
+	for _, tt := range tests {
+		if got := timeScore(tt.foundAt, tt.from, tt.until); math.Abs(got-tt.want) > 1e-9 {
+			t.Errorf("%s: timeScore = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestCompare(t *testing.T) {
+	lostAt := time.Date(2024, 3, 1, 8, 0, 0, 0, time.UTC)
+	lost := Lost{
+		Title:       "Dompet kulit hitam",
+		Description: "isi KTM dan kartu ATM",
+		Location:    "Gedung B lantai 2",
+		Category:    "Dompet",
+		LostFrom:    lostAt,
+		LostUntil:   lostAt.Add(4 * time.Hour),
+	}
+	tests := []struct {
+		name      string
+		found     Found
+		want      Score
+		minTotal  float64
+		maxTotal  float64
+		checkFull bool
+	}{
+		{
+			name: "identical",
+			found: Found{Title: lost.Title, Description: lost.Description, Location: lost.Location,
+				Category: lost.Category, FoundAt: lostAt.Add(time.Hour)},
+			want:      Score{Total: 1, Text: 1, Location: 1, Category: 1, Time: 1},
+			checkFull: true,
+		},
+		{
+			name: "same item, other room in same building",
+			found: Found{Title: "dompetnya hitam", Description: "ada KTM", Location: "Gedung B ruang 204",
+				Category: "dompet", FoundAt: lostAt.Add(2 * time.Hour)},
+			minTotal: HighConfidence,
+			maxTotal: 1,
+		},
+		{
+			name: "same floor, different building",
+			found: Found{Title: "Payung biru", Location: "lantai 2 gedung a", Category: "Lainnya",
+				FoundAt: lostAt.Add(2 * time.Hour)},
+			minTotal: 0,
+			maxTotal: MinScore,
+		},
+		{
+			name: "found before lost",
+			found: Found{Title: "Dompet kulit hitam", Location: "Kantin", Category: "Dompet",
+				FoundAt: lostAt.Add(-72 * time.Hour)},
+			minTotal: 0,
+			maxTotal: HighConfidence,
+		},
+	}
+	for _, tt := range tests {
+		got := Compare(tt.found, lost)
+		if tt.checkFull && got != tt.want {
+			t.Errorf("%s: Compare = %+v, want %+v", tt.name, got, tt.want)
+		}
+		if !tt.checkFull && (got.Total < tt.minTotal || got.Total > tt.maxTotal) {
+			t.Errorf("%s: Total = %v, want in [%v, %v] (%+v)", tt.name, got.Total, tt.minTotal, tt.maxTotal, got)
+		}
+		if got.Total < 0 || got.Total > 1 {
+			t.Errorf("%s: Total %v outside 0..1", tt.name, got.Total)
+		}
+	}
+}
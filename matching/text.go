@@ -0,0 +1,106 @@
+package matching
+
+import (
+	"strings"
+	"unicode"
+)
+
+// stopwords Indonesia + Inggris yang tidak membantu membedakan barang
+var stopwords = map[string]bool{
+	"yang": true, "dan": true, "di": true, "ke": true, "dari": true, "ini": true, "itu": true,
+	"dengan": true, "untuk": true, "pada": true, "ada": true, "saya": true, "aku": true,
+	"warna": true, "berwarna": true, "sebuah": true, "satu": true, "buah": true, "tidak": true,
+	"lt": true, "ruang": true, "the": true, "a": true, "an": true, "and": true, "of": true,
+	"in": true, "on": true, "at": true, "with": true, "my": true, "is": true, "color": true,
+	"colour": true,
+}
+
+// Tokens menormalisasi teks menjadi himpunan kata (huruf kecil, tanpa tanda baca dan stopword).
+// Akhiran "-nya" dibuang supaya "dompetnya" cocok dengan "dompet".
+func Tokens(s string) map[string]struct{} {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		if len(f) > 5 && strings.HasSuffix(f, "nya") {
+			f = strings.TrimSuffix(f, "nya")
+		}
+		if len(f) < 2 || stopwords[f] {
+			continue
+		}
+		tokens[f] = struct{}{}
+	}
+	return tokens
+}
+
+// locationWords adalah kata umum di nama lokasi yang tidak membedakan tempat
+var locationWords = map[string]bool{
+	"gedung": true, "lantai": true, "lt": true, "ruang": true, "ruangan": true, "r": true,
+	"building": true, "floor": true, "room": true, "di": true, "dekat": true, "gd": true,
+}
+
+// locationTokens seperti Tokens tetapi mempertahankan kata pendek ("b", "2") yang penting untuk lokasi
+func locationTokens(s string) map[string]struct{} {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make(map[string]struct{}, len(fields))
+	for _, f := range fields {
+		if !locationWords[f] {
+			tokens[f] = struct{}{}
+		}
+	}
+	return tokens
+}
+
+// buildingWords menandai kata berikutnya sebagai nama gedung ("gedung b", "building c")
+var buildingWords = map[string]bool{"gedung": true, "building": true, "gd": true}
+
+// building mengembalikan nama gedung pertama di lokasi s, atau "" jika tidak disebut
+func building(s string) string {
+	fields := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	for i := 0; i+1 < len(fields); i++ {
+		if buildingWords[fields[i]] && !locationWords[fields[i+1]] {
+			return fields[i+1]
+		}
+	}
+	return ""
+}
+
+// dice menghitung koefisien Sørensen–Dice dua himpunan token (0..1)
+func dice(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for t := range a {
+		if _, ok := b[t]; ok {
+			common++
+		}
+	}
+	return 2 * float64(common) / float64(len(a)+len(b))
+}
+
+// ContainsAll melaporkan apakah semua token di want ada di have
+func ContainsAll(have, want map[string]struct{}) bool {
+	for t := range want {
+		if _, ok := have[t]; !ok {
+			return false
+		}
+	}
+	return true
+}
+
+func union(a, b map[string]struct{}) map[string]struct{} {
+	out := make(map[string]struct{}, len(a)+len(b))
+	for t := range a {
+		out[t] = struct{}{}
+	}
+	for t := range b {
+		out[t] = struct{}{}
+	}
+	return out
+}
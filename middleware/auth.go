@@ -3,6 +3,7 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 
 	"filoti-backend/config"
 	"filoti-backend/models"
@@ -11,24 +12,55 @@ import (
 	"github.com/gin-gonic/gin"
 )
 
+// Rute yang tetap bisa diakses selama user wajib mengganti password
+var passwordChangeAllowed = map[string]bool{
+	"/me":          true,
+	"/me/password": true,
+	"/logout":      true,
+}
+
+// Rute yang tetap bisa diakses selama user wajib mengaktifkan 2FA (REQUIRE_ADMIN_2FA)
+var twoFactorSetupAllowed = map[string]bool{
+	"/me":            true,
+	"/me/2fa/setup":  true,
+	"/me/2fa/enable": true,
+	"/logout":        true,
+}
+
+// Rute yang ditolak untuk akun guest: akun itu dipakai bersama oleh semua pengunjung, jadi
+// satu guest tidak boleh melihat/menghapus session guest lain, mengubah password, email dan
+// 2FA akun tersebut, atau membuat data pribadi (laporan kehilangan berisi kontak, klaim,
+// pencarian tersimpan) yang akan terlihat dan bisa diubah oleh guest lain
+var guestDenied = map[string]bool{
+	"/me/password":              true,
+	"/me/email":                 true,
+	"/me/2fa/setup":             true,
+	"/me/2fa/enable":            true,
+	"/me/2fa/disable":           true,
+	"/me/2fa/recovery-codes":    true,
+	"/me/sessions":              true,
+	"/me/sessions/:id":          true,
+	"/me/claims":                true,
+	"/me/subscriptions":         true,
+	"/me/subscriptions/:id":     true,
+	"/posts/:id/claims":         true,
+	"/lost-reports":             true,
+	"/lost-reports/:id":         true,
+	"/lost-reports/:id/close":   true,
+	"/lost-reports/:id/matches": true,
+}
+
+// AuthRequired menerima cookie session atau header "Authorization: Bearer <access token>"
 func AuthRequired() gin.HandlerFunc {
 	return func(c *gin.Context) {
-		session := sessions.Default(c)
-		uid := session.Get("id")
-		if uid == nil {
-			c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
-			c.Abort()
+		userID, ok := bearerUserID(c)
+		if c.IsAborted() {
 			return
 		}
-		// Cast to uint
-		userID, ok := uid.(uint)
+		bearer := ok
 		if !ok {
-			// mungkin disimpan sebagai int?
-			if tmpInt, ok2 := uid.(int); ok2 {
-				userID = uint(tmpInt)
-			} else {
-				c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
-				c.Abort()
+			userID, ok = sessionUserID(c)
+			if !ok {
 				return
 			}
 		}
@@ -39,8 +71,102 @@ func AuthRequired() gin.HandlerFunc {
 			c.Abort()
 			return
 		}
-		// Simpan userID (dan user) di context
+		if user.IsSuspended() {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Account suspended", "reason": user.SuspendedReason})
+			c.Abort()
+			return
+		}
+		if blocked := accountRestriction(user, c.FullPath()); blocked != nil {
+			c.JSON(http.StatusForbidden, blocked)
+			c.Abort()
+			return
+		}
+		// Simpan userID (dan role-nya) di context
 		c.Set("userID", userID)
+		c.Set("userRole", user.Role)
+		c.Set("bearerAuth", bearer) // true jika login lewat access token, bukan cookie session
+		c.Next()
+	}
+}
+
+// accountRestriction mengembalikan body error 403 jika kondisi akun user melarang akses ke path,
+// atau nil jika boleh. Ganti password wajib diselesaikan dulu; gerbang 2FA baru berlaku setelahnya,
+// sehingga admin yang direset dan belum punya 2FA tetap bisa mengganti password lalu mengaktifkan 2FA.
+func accountRestriction(user models.User, path string) gin.H {
+	if user.IsGuest() && guestDenied[path] {
+		return gin.H{"error": "Not available for the guest account"}
+	}
+	// Setelah reset password oleh admin, user hanya boleh mengganti password (atau logout)
+	if user.MustChangePassword {
+		if !passwordChangeAllowed[path] {
+			return gin.H{"error": "Password change required", "must_change_password": true}
+		}
+		return nil
+	}
+	// Admin tanpa 2FA hanya boleh mengaktifkannya dulu jika kebijakannya diwajibkan
+	if config.TwoFactorRequired(user) && !user.TwoFactorEnabled() && !twoFactorSetupAllowed[path] {
+		return gin.H{"error": "Two-factor authentication setup required", "two_factor_setup_required": true}
+	}
+	return nil
+}
+
+// bearerUserID memverifikasi access token dari header Authorization.
+// ok=false tanpa abort berarti request tidak memakai Bearer token.
+func bearerUserID(c *gin.Context) (uint, bool) {
+	header := c.GetHeader("Authorization")
+	if len(header) < 7 || !strings.EqualFold(header[:7], "Bearer ") {
+		return 0, false
+	}
+	if config.Tokens == nil {
+		c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Bearer token authentication is not enabled"})
+		return 0, false
+	}
+	claims, err := config.Tokens.VerifyAccess(strings.TrimSpace(header[7:]))
+	if err == nil {
+		var userID uint
+		if userID, err = claims.UserID(); err == nil {
+			return userID, true
+		}
+	}
+	c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+	c.AbortWithStatusJSON(http.StatusUnauthorized, gin.H{"error": "Invalid or expired access token"})
+	return 0, false
+}
+
+// sessionUserID membaca user dari cookie session; menulis 401 jika tidak ada
+func sessionUserID(c *gin.Context) (uint, bool) {
+	session := sessions.Default(c)
+	uid := session.Get("id")
+	if uid == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		c.Abort()
+		return 0, false
+	}
+	// Cast to uint
+	userID, ok := uid.(uint)
+	if !ok {
+		// mungkin disimpan sebagai int?
+		if tmpInt, ok2 := uid.(int); ok2 {
+			userID = uint(tmpInt)
+		} else {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid session"})
+			c.Abort()
+			return 0, false
+		}
+	}
+	return userID, true
+}
+
+// RequirePermission harus dipasang setelah AuthRequired; menolak user yang role-nya tidak punya izin perm.
+// Role dibaca ulang dari database di AuthRequired setiap request, jadi perubahan role langsung berlaku.
+func RequirePermission(perm models.Permission) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, _ := c.Get("userRole")
+		if r, ok := role.(models.Role); !ok || !r.Can(perm) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "Forbidden: missing permission " + string(perm)})
+			c.Abort()
+			return
+		}
 		c.Next()
 	}
 }
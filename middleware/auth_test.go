@@ -0,0 +1,66 @@
+package middleware
+
+import (
+	"testing"
+	"time"
+
+	"filoti-backend/models"
+)
+
+func TestAccountRestriction(t *testing.T) {
+	t.Setenv("REQUIRE_ADMIN_2FA", "true")
+	enabled := time.Now()
+
+	resetAdmin := models.User{Username: "admin", Role: models.RoleSuperAdmin, MustChangePassword: true}
+	newAdmin := models.User{Username: "admin", Role: models.RoleSuperAdmin}
+	admin2FA := models.User{Username: "admin", Role: models.RoleSuperAdmin, TOTPEnabledAt: &enabled}
+	student := models.User{Username: "budi", Role: models.RoleStudent}
+	resetStudent := models.User{Username: "budi", Role: models.RoleStudent, MustChangePassword: true}
+	guest := models.User{Username: models.GuestUsername, Role: models.RoleStudent}
+
+	tests := []struct {
+		name    string
+		user    models.User
+		path    string
+		allowed bool
+	}{
+		// Admin yang direset dan belum punya 2FA: ganti password dulu, lalu setup 2FA
+		{"reset admin changes password", resetAdmin, "/me/password", true},
+		{"reset admin reads profile", resetAdmin, "/me", true},
+		{"reset admin logs out", resetAdmin, "/logout", true},
+		{"reset admin cannot set up 2FA yet", resetAdmin, "/me/2fa/setup", false},
+		{"reset admin cannot review claims", resetAdmin, "/admin/claims", false},
+		{"admin without 2FA sets it up", newAdmin, "/me/2fa/setup", true},
+		{"admin without 2FA enables it", newAdmin, "/me/2fa/enable", true},
+		{"admin without 2FA is blocked elsewhere", newAdmin, "/admin/claims", false},
+		{"admin without 2FA cannot change email", newAdmin, "/me/email", false},
+		{"admin with 2FA", admin2FA, "/admin/claims", true},
+		{"student is not required to use 2FA", student, "/me/email", true},
+		{"reset student", resetStudent, "/notifications", false},
+		{"guest cannot change password", guest, "/me/password", false},
+		{"guest cannot list sessions", guest, "/me/sessions", false},
+		{"guest cannot revoke sessions", guest, "/me/sessions/:id", false},
+		{"guest cannot file lost reports", guest, "/lost-reports", false},
+		{"guest cannot close lost reports", guest, "/lost-reports/:id/close", false},
+		{"guest cannot claim", guest, "/posts/:id/claims", false},
+		{"guest reads notifications", guest, "/notifications", true},
+		{"guest reads post history", guest, "/posts/:id/history", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blocked := accountRestriction(tt.user, tt.path)
+			if (blocked == nil) != tt.allowed {
+				t.Errorf("accountRestriction(%s) = %v, allowed want %v", tt.path, blocked, tt.allowed)
+			}
+		})
+	}
+}
+
+// Tanpa REQUIRE_ADMIN_2FA admin tanpa 2FA tidak dibatasi
+func TestAccountRestrictionWithoutPolicy(t *testing.T) {
+	t.Setenv("REQUIRE_ADMIN_2FA", "")
+	admin := models.User{Username: "admin", Role: models.RoleSuperAdmin}
+	if blocked := accountRestriction(admin, "/admin/claims"); blocked != nil {
+		t.Errorf("accountRestriction = %v, want nil", blocked)
+	}
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditLog mencatat siapa melakukan apa terhadap entitas mana, beserta snapshot sebelum/sesudahnya.
+// Tabel ini append-only: baris tidak pernah diubah atau dihapus.
+type AuditLog struct {
+	ID         uint            `gorm:"primaryKey" json:"id"`
+	ActorID    *uint           `gorm:"index" json:"actor_id"`                // NULL untuk aksi tanpa login (mis. signup gagal)
+	ActorName  string          `gorm:"size:100" json:"actor_name"`           // Disalin agar tetap terbaca walau user dihapus
+	Action     string          `gorm:"size:50;not null;index" json:"action"` // Contoh: "user.suspend", "post.update"
+	EntityType string          `gorm:"size:50;index:idx_audit_entity" json:"entity_type"`
+	EntityID   string          `gorm:"size:50;index:idx_audit_entity" json:"entity_id"`
+	Before     json.RawMessage `gorm:"type:jsonb" json:"before,omitempty"`
+	After      json.RawMessage `gorm:"type:jsonb" json:"after,omitempty"`
+	IP         string          `gorm:"size:64" json:"ip"`
+	UserAgent  string          `json:"user_agent"`
+	CreatedAt  time.Time       `gorm:"autoCreateTime;index" json:"created_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type ClaimStatus string
+
+const (
+	ClaimPending  ClaimStatus = "pending"
+	ClaimApproved ClaimStatus = "approved"
+	ClaimRejected ClaimStatus = "rejected"
+)
+
+// Claim adalah pengajuan user yang merasa kehilangan barang pada sebuah post.
+// Admin meninjau klaim; klaim yang disetujui menutup post atas nama pengklaim.
+type Claim struct {
+	ID          uint        `gorm:"primaryKey" json:"id"`
+	PostID      uint        `gorm:"not null;index" json:"post_id"`
+	ClaimantID  uint        `gorm:"not null;index" json:"claimant_id"`
+	Description string      `gorm:"type:text;not null" json:"description"` // Ciri-ciri barang sebagai bukti kepemilikan
+	ProofImage  string      `json:"proof_image,omitempty"`                 // URL dari POST /uploads, opsional
+	Status      ClaimStatus `gorm:"size:20;not null;default:'pending';index" json:"status"`
+	ReviewedBy  *uint       `json:"reviewed_by,omitempty"`
+	ReviewNote  string      `json:"review_note,omitempty"`
+	ReviewedAt  *time.Time  `json:"reviewed_at,omitempty"`
+	CreatedAt   time.Time   `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Post     *Post `gorm:"foreignKey:PostID;constraint:-" json:"post,omitempty"` // Tanpa FK: klaim tetap tersimpan setelah post di-purge
+	Claimant *User `gorm:"foreignKey:ClaimantID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// MarshalJSON menampilkan claimant sebagai UserSummary, bukan seluruh data user
+func (c Claim) MarshalJSON() ([]byte, error) {
+	type claim Claim
+	return json.Marshal(struct {
+		claim
+		Claimant *UserSummary `json:"claimant,omitempty"`
+	}{claim(c), c.Claimant.Summary()})
+}
@@ -0,0 +1,48 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+type LostReportStatus string
+
+const (
+	LostReportOpen     LostReportStatus = "open"     // Masih dicari
+	LostReportMatched  LostReportStatus = "matched"  // Admin menautkan ke post barang temuan
+	LostReportResolved LostReportStatus = "resolved" // Barang sudah kembali ke pelapor
+	LostReportClosed   LostReportStatus = "closed"   // Ditutup pelapor/admin tanpa hasil
+)
+
+// LostReport adalah laporan "saya kehilangan X" dari mahasiswa, pasangan dari
+// Post yang dicatat staf untuk barang temuan.
+type LostReport struct {
+	ID               uint             `gorm:"primaryKey" json:"id"`
+	ReporterID       uint             `gorm:"not null;index" json:"reporter_id"`
+	Title            string           `gorm:"not null" json:"title"`
+	Description      string           `gorm:"type:text" json:"description"`
+	ItemType         string           `gorm:"index" json:"itemType"`
+	LastSeenLocation string           `gorm:"index" json:"last_seen_location"`
+	LostFrom         time.Time        `json:"lost_from"`  // Awal rentang waktu barang hilang
+	LostUntil        time.Time        `json:"lost_until"` // Akhir rentang waktu barang hilang
+	ImageURL         string           `json:"image_url,omitempty"`
+	ContactInfo      string           `json:"contact_info,omitempty"`
+	Status           LostReportStatus `gorm:"size:20;not null;default:'open';index" json:"status"`
+	MatchedPostID    *uint            `gorm:"index" json:"matched_post_id"`
+	MatchedBy        *uint            `json:"matched_by,omitempty"`
+	MatchedAt        *time.Time       `json:"matched_at,omitempty"`
+	CreatedAt        time.Time        `gorm:"autoCreateTime;index" json:"created_at"`
+	UpdatedAt        time.Time        `gorm:"autoUpdateTime" json:"updated_at"`
+
+	Reporter    *User `gorm:"foreignKey:ReporterID;constraint:OnDelete:CASCADE" json:"-"`
+	MatchedPost *Post `gorm:"foreignKey:MatchedPostID;constraint:OnDelete:SET NULL" json:"matched_post,omitempty"`
+}
+
+// MarshalJSON menampilkan reporter sebagai UserSummary, bukan seluruh data user
+func (r LostReport) MarshalJSON() ([]byte, error) {
+	type lostReport LostReport
+	return json.Marshal(struct {
+		lostReport
+		Reporter *UserSummary `json:"reporter,omitempty"`
+	}{lostReport(r), r.Reporter.Summary()})
+}
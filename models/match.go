@@ -0,0 +1,18 @@
+package models
+
+import (
+	"time"
+)
+
+// MatchSuggestion mencatat pasangan post–laporan kehilangan berskor tinggi
+// yang sudah diberitahukan ke pelapor, supaya notifikasi tidak terkirim dua kali.
+type MatchSuggestion struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	PostID       uint      `gorm:"not null;uniqueIndex:idx_match_post_lost" json:"post_id"`
+	LostReportID uint      `gorm:"not null;uniqueIndex:idx_match_post_lost;index" json:"lost_report_id"`
+	Score        float64   `json:"score"`
+	CreatedAt    time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Post       *Post       `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"-"`
+	LostReport *LostReport `gorm:"foreignKey:LostReportID;constraint:OnDelete:CASCADE" json:"-"`
+}
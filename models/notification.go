@@ -1,18 +1,48 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
+// NotificationType adalah jenis event notifikasi; frontend memakai nilai ini
+// untuk menentukan ikon/warna, dan backend untuk memilih template pesan.
+type NotificationType string
+
+const (
+	NotificationGeneral          NotificationType = "general" // Notifikasi lama tanpa jenis
+	NotificationPostCreated      NotificationType = "post_created"
+	NotificationPostDone         NotificationType = "post_done"
+	NotificationPostUpdated      NotificationType = "post_updated"
+	NotificationPostDeleted      NotificationType = "post_deleted"
+	NotificationPostRestored     NotificationType = "post_restored"
+	NotificationPostStateChanged NotificationType = "post_state_changed"
+
+	NotificationClaimSubmitted NotificationType = "claim_submitted"
+	NotificationClaimApproved  NotificationType = "claim_approved"
+	NotificationClaimRejected  NotificationType = "claim_rejected"
+
+	NotificationLostReportCreated NotificationType = "lost_report_created"
+	NotificationLostReportMatched NotificationType = "lost_report_matched"
+	NotificationMatchSuggested    NotificationType = "match_suggested"
+
+	NotificationSubscriptionMatch NotificationType = "subscription_match"
+)
+
+// Notification adalah baris notifikasi per penerima; setiap event di-fan-out
+// menjadi satu baris untuk setiap user yang perlu diberi tahu.
 type Notification struct {
-	ID uint `gorm:"primaryKey" json:"id"`
-	// UserID    uint      `gorm:"not null;index" json:"user_id"` // <-- DIHAPUS sesuai permintaan
-	PostID    uint      `gorm:"not null;index" json:"post_id"`
-	Message   string    `gorm:"not null" json:"message"`
-	IsRead    bool      `gorm:"not null;default:false" json:"is_read"`
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	UserID    uint             `gorm:"index" json:"user_id"` // Penerima notifikasi
+	PostID    *uint            `gorm:"index" json:"post_id"` // NULL jika post sudah di-purge
+	Type      NotificationType `gorm:"size:50;not null;default:'general';index" json:"type"`
+	Payload   json.RawMessage  `gorm:"type:jsonb" json:"payload"` // Data event untuk merender pesan
+	Message   string           `gorm:"not null" json:"message"`   // Pesan hasil render saat dibuat
+	IsRead    bool             `gorm:"not null;default:false" json:"is_read"`
+	ReadAt    *time.Time       `json:"read_at,omitempty"`
+	CreatedAt time.Time        `gorm:"autoCreateTime" json:"created_at"`
 
 	// relasi opsional
-	// User *User `gorm:"foreignKey:UserID" json:"-"` // <-- DIHAPUS sesuai permintaan
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
 	Post *Post `gorm:"foreignKey:PostID" json:"-"`
 }
@@ -1,18 +1,41 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
+
+	"gorm.io/gorm"
 )
 
 type Post struct {
 	ID         uint      `gorm:"primaryKey" json:"id"`
 	ImageURL   string    `json:"image_url"`
 	Title      string    `json:"title"`
-	Ruangan    string    `json:"ruangan"`
+	Ruangan    string    `gorm:"index" json:"ruangan"`
 	Keterangan string    `json:"keterangan"`
-	ItemType   string    `json:"itemType"`
-	CreatedAt  time.Time `gorm:"autoCreateTime" json:"created_at"`
+	ItemType   string    `gorm:"index" json:"itemType"`
+	AuthorID   *uint     `gorm:"index" json:"author_id"` // User yang mencatat post ini
+	CreatedAt  time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+	Version    uint      `gorm:"not null;default:1" json:"version"` // Naik setiap update, dipakai untuk ETag/If-Match
+
+	// Soft delete: post yang dihapus masuk tempat sampah dan di-purge setelah masa retensi
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"deleted_at,omitempty"`
+	DeletedBy *uint          `json:"deleted_by,omitempty"`
+
+	ImageVariants *ImageVariants `gorm:"-" json:"image_variants,omitempty"` // Diisi dari tabel uploads
 
+	Author        *User          `gorm:"foreignKey:AuthorID;constraint:OnDelete:SET NULL" json:"-"` // Lihat MarshalJSON
+	Deleter       *User          `gorm:"foreignKey:DeletedBy;constraint:OnDelete:SET NULL" json:"-"`
 	Status        Status         `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"status"` // Status sebagai relasi satu-ke-satu
-	Notifications []Notification `gorm:"foreignKey:PostID;constraint:OnDelete:CASCADE" json:"-"`
+	Notifications []Notification `gorm:"foreignKey:PostID;constraint:OnDelete:SET NULL" json:"-"`     // Riwayat notifikasi tetap ada walau post di-purge
+}
+
+// MarshalJSON menampilkan author dan deleter sebagai UserSummary, bukan seluruh data user
+func (p Post) MarshalJSON() ([]byte, error) {
+	type post Post // Tanpa method, agar tidak rekursif
+	return json.Marshal(struct {
+		post
+		Author  *UserSummary `json:"author,omitempty"`
+		Deleter *UserSummary `json:"deleter,omitempty"`
+	}{post(p), p.Author.Summary(), p.Deleter.Summary()})
 }
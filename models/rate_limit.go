@@ -0,0 +1,13 @@
+package models
+
+import (
+	"time"
+)
+
+// RateLimit adalah counter rate limit bersama untuk deployment serverless (lihat ratelimit.Postgres)
+type RateLimit struct {
+	Key         string     `gorm:"primaryKey;size:255"`
+	Count       int        `gorm:"not null;default:0"`
+	ResetAt     time.Time  `gorm:"not null;index"`
+	LockedUntil *time.Time `gorm:"index"`
+}
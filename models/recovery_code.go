@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// RecoveryCode adalah kode cadangan sekali pakai untuk login 2FA jika perangkat authenticator hilang.
+// Hanya hash-nya yang disimpan; kode asli ditampilkan sekali saat dibuat.
+type RecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"user_id"`
+	CodeHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	UsedAt    *time.Time `json:"used_at,omitempty"`
+	CreatedAt time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
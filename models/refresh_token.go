@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// RefreshToken disimpan sebagai hash. Setiap refresh menerbitkan token baru dalam family yang sama
+// dan me-revoke token lama; jika token yang sudah dipakai muncul lagi, seluruh family di-revoke.
+type RefreshToken struct {
+	ID           uint       `gorm:"primaryKey" json:"id"`
+	UserID       uint       `gorm:"not null;index" json:"user_id"`
+	FamilyID     string     `gorm:"size:64;not null;index" json:"family_id"`
+	TokenHash    string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	ExpiresAt    time.Time  `gorm:"not null" json:"expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty"`
+	ReplacedByID *uint      `json:"replaced_by_id,omitempty"`
+	IP           string     `gorm:"size:64" json:"ip"`
+	UserAgent    string     `json:"user_agent"`
+	CreatedAt    time.Time  `gorm:"autoCreateTime" json:"created_at"`
+
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
@@ -0,0 +1,116 @@
+package models
+
+// Role menentukan apa saja yang boleh dilakukan user; izin detailnya ada di rolePermissions
+type Role string
+
+const (
+	RoleStudent     Role = "student"
+	RoleStaff       Role = "staff"
+	RoleDeskOfficer Role = "desk_officer"
+	RoleModerator   Role = "moderator"
+	RoleSuperAdmin  Role = "super_admin"
+)
+
+// Roles berurutan dari yang paling sedikit izinnya
+var Roles = []Role{RoleStudent, RoleStaff, RoleDeskOfficer, RoleModerator, RoleSuperAdmin}
+
+// Permission adalah nama izin dengan format "resource:aksi", dipakai di middleware.RequirePermission
+type Permission string
+
+const (
+	PermPostsCreate       Permission = "posts:create"
+	PermPostsUpdate       Permission = "posts:update"
+	PermPostsDelete       Permission = "posts:delete"
+	PermPostsRestore      Permission = "posts:restore"
+	PermPostsTransition   Permission = "posts:transition" // Tandai selesai / ubah state
+	PermMatchesRead       Permission = "matches:read"
+	PermClaimsCreate      Permission = "claims:create"
+	PermClaimsReview      Permission = "claims:review"
+	PermLostReportsCreate Permission = "lost_reports:create"
+	PermLostReportsRead   Permission = "lost_reports:read_all"
+	PermLostReportsLink   Permission = "lost_reports:link"
+	PermTrashManage       Permission = "trash:manage"
+	PermDeskNotifications Permission = "notifications:desk" // Menerima notifikasi operasional meja lost & found
+	PermRolesAssign       Permission = "roles:assign"
+	PermUsersManage       Permission = "users:manage" // Cari, suspend, reset password dan hapus user
+	PermAuditRead         Permission = "audit:read"
+)
+
+// Izin setiap role; role yang lebih tinggi mewarisi izin role di bawahnya
+var rolePermissions = func() map[Role]map[Permission]bool {
+	grants := map[Role][]Permission{
+		RoleStudent:     {PermPostsCreate, PermClaimsCreate, PermLostReportsCreate},
+		RoleStaff:       {PermPostsUpdate, PermMatchesRead, PermLostReportsRead, PermDeskNotifications},
+		RoleDeskOfficer: {PermPostsTransition, PermClaimsReview, PermLostReportsLink},
+		RoleModerator:   {PermPostsDelete, PermPostsRestore, PermTrashManage, PermAuditRead},
+		RoleSuperAdmin:  {PermRolesAssign, PermUsersManage},
+	}
+	perms := make(map[Role]map[Permission]bool, len(Roles))
+	inherited := map[Permission]bool{}
+	for _, role := range Roles {
+		for _, p := range grants[role] {
+			inherited[p] = true
+		}
+		set := make(map[Permission]bool, len(inherited))
+		for p := range inherited {
+			set[p] = true
+		}
+		perms[role] = set
+	}
+	return perms
+}()
+
+// Valid mengembalikan true jika role dikenal
+func (r Role) Valid() bool {
+	_, ok := rolePermissions[r]
+	return ok
+}
+
+// Rank adalah posisi role di Roles (makin besar makin banyak izinnya); -1 jika tidak dikenal
+func (r Role) Rank() int {
+	for i, role := range Roles {
+		if role == r {
+			return i
+		}
+	}
+	return -1
+}
+
+// Can memeriksa apakah role memiliki izin tertentu
+func (r Role) Can(p Permission) bool {
+	return rolePermissions[r][p]
+}
+
+// Permissions mengembalikan semua izin role, urut sesuai deklarasi
+func (r Role) Permissions() []Permission {
+	var perms []Permission
+	for _, p := range allPermissions {
+		if r.Can(p) {
+			perms = append(perms, p)
+		}
+	}
+	return perms
+}
+
+// IsAdmin: role yang dianggap admin oleh frontend lama (punya akses ke panel admin)
+func (r Role) IsAdmin() bool {
+	return r.Can(PermClaimsReview)
+}
+
+var allPermissions = []Permission{
+	PermPostsCreate, PermPostsUpdate, PermPostsDelete, PermPostsRestore, PermPostsTransition,
+	PermMatchesRead, PermClaimsCreate, PermClaimsReview,
+	PermLostReportsCreate, PermLostReportsRead, PermLostReportsLink,
+	PermTrashManage, PermAuditRead, PermDeskNotifications, PermRolesAssign, PermUsersManage,
+}
+
+// RolesWith mengembalikan role yang memiliki izin p (untuk query, misalnya penerima notifikasi)
+func RolesWith(p Permission) []Role {
+	var roles []Role
+	for _, r := range Roles {
+		if r.Can(p) {
+			roles = append(roles, r)
+		}
+	}
+	return roles
+}
@@ -2,17 +2,108 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 )
 
+// PostState adalah tahap siklus hidup barang temuan
+type PostState string
+
+const (
+	StateReported     PostState = "reported"      // Baru dilaporkan/dicatat
+	StateInStorage    PostState = "in_storage"    // Sudah disimpan di meja lost & found
+	StateClaimPending PostState = "claim_pending" // Ada klaim yang menunggu review
+	StateReturned     PostState = "returned"      // Sudah diserahkan ke pemilik
+	StateDonated      PostState = "donated"       // Tidak diambil, didonasikan
+	StateDisposed     PostState = "disposed"      // Tidak diambil, dimusnahkan
+	StateReopened     PostState = "reopened"      // Dibuka kembali setelah ditutup
+)
+
+// stateTransitions mendaftar transisi yang diizinkan dari setiap state
+var stateTransitions = map[PostState][]PostState{
+	StateReported:     {StateInStorage, StateClaimPending, StateReturned, StateDonated, StateDisposed},
+	StateInStorage:    {StateClaimPending, StateReturned, StateDonated, StateDisposed},
+	StateClaimPending: {StateReported, StateInStorage, StateReopened, StateReturned},
+	StateReturned:     {StateReopened},
+	StateDonated:      {StateReopened},
+	StateDisposed:     {StateReopened},
+	StateReopened:     {StateInStorage, StateClaimPending, StateReturned, StateDonated, StateDisposed},
+}
+
+// Valid melaporkan apakah s adalah state yang dikenal
+func (s PostState) Valid() bool {
+	_, ok := stateTransitions[s]
+	return ok
+}
+
+// IsActive bernilai true selama barang masih menunggu pemiliknya
+func (s PostState) IsActive() bool {
+	switch s {
+	case StateReturned, StateDonated, StateDisposed:
+		return false
+	}
+	return true
+}
+
+// CanTransition melaporkan apakah perpindahan from -> to diizinkan
+func CanTransition(from, to PostState) bool {
+	for _, allowed := range stateTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowedTransitions mengembalikan state tujuan yang valid dari s
+func (s PostState) AllowedTransitions() []PostState {
+	return stateTransitions[s]
+}
+
 type Status struct {
 	ID          uint      `gorm:"primaryKey" json:"id"`
-	PostID      uint      `gorm:"uniqueIndex;not null" json:"post_id"` // Pastikan PostID unik untuk status
-	Status      int       `gorm:"default:1" json:"status"`             // 1: Active, 0: Done (atau angka lain sesuai kebutuhan)
-	ClaimerName string    `json:"claimer_name,omitempty"`              // Nama pengambil/penemu, opsional
-	ProofImage  string    `json:"proof_image,omitempty"`               // URL bukti gambar, opsional
-	UpdatedBy   uint      `json:"updated_by"`                          // ID user yang mengubah status
+	PostID      uint      `gorm:"uniqueIndex;not null" json:"post_id"`                    // Pastikan PostID unik untuk status
+	State       PostState `gorm:"size:30;not null;default:'reported';index" json:"state"` // State siklus hidup, lihat PostState
+	Status      int       `gorm:"default:1" json:"status"`                                // Turunan dari State untuk klien lama: 1 Active, 0 Done
+	ClaimerID   *uint     `json:"claimer_id,omitempty"`                                   // User pengambil jika ditutup lewat klaim
+	ClaimerName string    `json:"claimer_name,omitempty"`                                 // Nama pengambil/penemu, opsional
+	ProofImage  string    `json:"proof_image,omitempty"`                                  // URL bukti gambar, opsional
+	UpdatedBy   uint      `json:"updated_by"`                                             // ID user yang mengubah status
 	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+	Version     uint      `gorm:"not null;default:1" json:"version"` // Naik setiap transisi, bagian dari ETag post
 	// relasi ke post (optional)
 	Post *Post `gorm:"foreignKey:PostID" json:"-"`
 }
+
+// SetState mengubah State sekaligus menyinkronkan kolom Status lama
+func (s *Status) SetState(state PostState) {
+	s.State = state
+	if state.IsActive() {
+		s.Status = 1
+	} else {
+		s.Status = 0
+	}
+}
+
+// StatusHistory mencatat setiap transisi state sebuah post
+type StatusHistory struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	PostID    uint      `gorm:"not null;index" json:"post_id"`
+	FromState PostState `gorm:"size:30" json:"from_state"` // Kosong untuk entri pertama saat post dibuat
+	ToState   PostState `gorm:"size:30;not null" json:"to_state"`
+	ActorID   *uint     `gorm:"index" json:"actor_id"`
+	Note      string    `gorm:"type:text" json:"note,omitempty"`
+	CreatedAt time.Time `gorm:"autoCreateTime;index" json:"created_at"`
+
+	Post  *Post `gorm:"foreignKey:PostID;constraint:-" json:"-"` // Tanpa FK: riwayat tetap tersimpan setelah post di-purge
+	Actor *User `gorm:"foreignKey:ActorID;constraint:OnDelete:SET NULL" json:"-"`
+}
+
+// MarshalJSON menampilkan actor sebagai UserSummary, bukan seluruh data user
+func (h StatusHistory) MarshalJSON() ([]byte, error) {
+	type statusHistory StatusHistory
+	return json.Marshal(struct {
+		statusHistory
+		Actor *UserSummary `json:"actor,omitempty"`
+	}{statusHistory(h), h.Actor.Summary()})
+}
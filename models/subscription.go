@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// Subscription adalah pencarian tersimpan; user diberi notifikasi saat ada post baru yang cocok.
+// Kriteria yang kosong diabaikan, tetapi minimal satu harus diisi.
+type Subscription struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UserID        uint       `gorm:"not null;index" json:"user_id"`
+	Name          string     `json:"name"`
+	Query         string     `json:"query"`   // Kata kunci; semua kata harus muncul di judul/keterangan/ruangan
+	Ruangan       string     `json:"ruangan"` // Cocok jika ruangan post mengandung teks ini
+	ItemType      string     `json:"itemType"`
+	Active        bool       `gorm:"not null;default:true" json:"active"`
+	LastMatchedAt *time.Time `json:"last_matched_at,omitempty"`
+	CreatedAt     time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt     time.Time  `gorm:"autoUpdateTime" json:"updated_at"`
+
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
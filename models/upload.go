@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+)
+
+// Upload mencatat file yang di-upload lewat POST /uploads.
+// URL-nya yang boleh dipakai sebagai image_url / proof_image di endpoint post.
+type Upload struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Key         string    `gorm:"uniqueIndex;not null" json:"key"`
+	URL         string    `gorm:"uniqueIndex;not null" json:"url"`
+	ContentType string    `gorm:"not null" json:"content_type"`
+	Size        int64     `json:"size"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	UploadedBy  uint      `gorm:"index" json:"uploaded_by"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	Variants ImageVariants `gorm:"embedded;embeddedPrefix:variant_" json:"variants"`
+}
+
+// ImageVariants berisi URL versi gambar yang sudah diperkecil (JPEG)
+type ImageVariants struct {
+	Thumbnail string `json:"thumbnail"`
+	Medium    string `json:"medium"`
+	Full      string `json:"full"`
+}
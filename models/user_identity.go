@@ -0,0 +1,19 @@
+package models
+
+import (
+	"time"
+)
+
+// UserIdentity menautkan user ke akun di provider SSO (OIDC) lewat claim sub.
+// Satu user boleh punya beberapa identitas, satu per provider.
+type UserIdentity struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	UserID      uint      `gorm:"not null;index" json:"user_id"`
+	Provider    string    `gorm:"size:50;not null;uniqueIndex:idx_identity_provider_subject" json:"provider"`
+	Subject     string    `gorm:"size:255;not null;uniqueIndex:idx_identity_provider_subject" json:"subject"`
+	Email       string    `gorm:"size:255" json:"email"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	LastLoginAt time.Time `json:"last_login_at"`
+
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// UserSession adalah session login browser yang disimpan di server (lihat package sessionstore).
+// Cookie hanya berisi token acak; yang disimpan di sini hanya hash-nya.
+type UserSession struct {
+	ID         uint       `gorm:"primaryKey" json:"id"`
+	TokenHash  string     `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	UserID     *uint      `gorm:"index" json:"user_id"` // NULL untuk session tanpa login
+	Data       []byte     `json:"-"`                    // Nilai session (gob)
+	IP         string     `gorm:"size:64" json:"ip"`
+	UserAgent  string     `json:"user_agent"`
+	CreatedAt  time.Time  `gorm:"autoCreateTime" json:"created_at"`
+	LastSeenAt time.Time  `json:"last_seen_at"`
+	ExpiresAt  time.Time  `gorm:"not null;index" json:"expires_at"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
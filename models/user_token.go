@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+)
+
+// UserTokenPurpose membedakan kegunaan token sekali pakai yang dikirim lewat email
+type UserTokenPurpose string
+
+const (
+	TokenPasswordReset     UserTokenPurpose = "password_reset"
+	TokenEmailVerification UserTokenPurpose = "email_verification"
+	TokenLoginChallenge    UserTokenPurpose = "login_2fa" // Langkah kedua login setelah password benar
+)
+
+// UserToken adalah token sekali pakai yang kedaluwarsa (reset password, verifikasi email).
+// Yang disimpan hanya hash-nya; token asli hanya ada di email yang dikirim ke user.
+type UserToken struct {
+	ID        uint             `gorm:"primaryKey" json:"id"`
+	UserID    uint             `gorm:"not null;index" json:"user_id"`
+	Purpose   UserTokenPurpose `gorm:"size:30;not null" json:"purpose"`
+	TokenHash string           `gorm:"size:64;not null;uniqueIndex" json:"-"`
+	Email     string           `gorm:"size:255;not null" json:"email"` // Alamat tujuan email token ini
+	ExpiresAt time.Time        `gorm:"not null" json:"expires_at"`
+	UsedAt    *time.Time       `json:"used_at,omitempty"`
+	CreatedAt time.Time        `gorm:"autoCreateTime" json:"created_at"`
+
+	User *User `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// Usable mengembalikan true jika token belum dipakai dan belum kedaluwarsa pada waktu now
+func (t UserToken) Usable(now time.Time) bool {
+	return t.UsedAt == nil && now.Before(t.ExpiresAt)
+}
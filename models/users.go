@@ -4,10 +4,66 @@ import (
 	"time"
 )
 
+// GuestUsername adalah akun bersama yang dipakai semua pengunjung lewat /guest-login
+const GuestUsername = "guest"
+
 type User struct {
 	ID        uint      `gorm:"primaryKey" json:"id"`
 	Username  string    `gorm:"uniqueIndex;not null" json:"username"`
 	Password  string    `gorm:"not null" json:"-"`
-	IsAdmin   bool      `gorm:"default:false" json:"is_admin"` // Tambahkan field IsAdmin
+	Role      Role      `gorm:"size:30;not null;default:'student';index" json:"role"` // Menggantikan kolom is_admin
 	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+
+	SuspendedAt        *time.Time `json:"suspended_at,omitempty"` // Akun yang disuspend tidak bisa login
+	SuspendedReason    string     `json:"suspended_reason,omitempty"`
+	MustChangePassword bool       `gorm:"not null;default:false" json:"must_change_password"` // Diset setelah reset password oleh admin
+
+	// Email opsional, hanya diisi setelah terverifikasi (lihat /me/email) dan dipakai untuk lupa password
+	Email           *string    `gorm:"size:255;uniqueIndex" json:"email,omitempty"`
+	EmailVerifiedAt *time.Time `json:"email_verified_at,omitempty"`
+
+	// TOTP (RFC 6238). Secret terisi sejak setup, tapi 2FA baru aktif setelah TOTPEnabledAt diset.
+	TOTPSecret    string     `gorm:"size:64" json:"-"`
+	TOTPEnabledAt *time.Time `json:"totp_enabled_at,omitempty"`
+	TOTPLastStep  int64      `gorm:"not null;default:0" json:"-"` // Langkah waktu terakhir yang dipakai, cegah replay
+}
+
+// TwoFactorEnabled mengembalikan true jika login user memerlukan kode TOTP
+func (u User) TwoFactorEnabled() bool {
+	return u.TOTPEnabledAt != nil
+}
+
+// UserSummary adalah data user yang aman ditampilkan ke user lain (author post, pelaku, pengklaim).
+// Relasi *User di model lain bertag json:"-" dan diserialisasi lewat ringkasan ini.
+type UserSummary struct {
+	ID       uint   `json:"id"`
+	Username string `json:"username"`
+}
+
+// Summary mengembalikan nil jika relasi user tidak dimuat
+func (u *User) Summary() *UserSummary {
+	if u == nil {
+		return nil
+	}
+	return &UserSummary{ID: u.ID, Username: u.Username}
+}
+
+// IsGuest mengembalikan true untuk akun guest bersama
+func (u User) IsGuest() bool {
+	return u.Username == GuestUsername
+}
+
+// IsSuspended mengembalikan true jika akun sedang disuspend
+func (u User) IsSuspended() bool {
+	return u.SuspendedAt != nil
+}
+
+// Can memeriksa izin user berdasarkan role-nya
+func (u User) Can(p Permission) bool {
+	return u.Role.Can(p)
+}
+
+// IsAdmin dipertahankan untuk respons is_admin yang masih dipakai frontend
+func (u User) IsAdmin() bool {
+	return u.Role.IsAdmin()
 }
@@ -0,0 +1,254 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+)
+
+var ErrInvalidIDToken = errors.New("oidc: invalid id token")
+
+const (
+	clockSkew       = time.Minute
+	jwksMinRefresh  = time.Minute // Batas refresh JWKS saat kid tidak dikenal (rotasi kunci)
+	maxRSAKeyBits   = 8192
+	minRSAKeyBits   = 2048
+	groupsClaimNone = ""
+)
+
+// Claims adalah isi ID token yang dipakai aplikasi. Raw berisi semua claim
+// sehingga claim grup bisa dibaca dengan nama yang dikonfigurasi.
+type Claims struct {
+	Subject           string
+	Email             string
+	EmailVerified     bool
+	PreferredUsername string
+	Name              string
+	Raw               map[string]interface{}
+}
+
+// Groups membaca claim grup bernama name (array string atau string tunggal)
+func (c *Claims) Groups(name string) []string {
+	if name == groupsClaimNone {
+		return nil
+	}
+	switch v := c.Raw[name].(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		groups := make([]string, 0, len(v))
+		for _, g := range v {
+			if s, ok := g.(string); ok {
+				groups = append(groups, s)
+			}
+		}
+		return groups
+	}
+	return nil
+}
+
+type keySet struct {
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// key mencari kunci RSA berdasarkan kid; JWKS diambil ulang jika kid belum dikenal
+func (p *Provider) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.keys != nil {
+		if k, ok := p.keys.keys[kid]; ok {
+			return k, nil
+		}
+		if p.now().Sub(p.keys.fetchedAt) < jwksMinRefresh {
+			return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidIDToken, kid)
+		}
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := p.getJSON(ctx, doc.JWKSURI, &set); err != nil {
+		return nil, fmt.Errorf("oidc: fetch jwks: %w", err)
+	}
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" || (k.Use != "" && k.Use != "sig") || (k.Alg != "" && k.Alg != "RS256") {
+			continue
+		}
+		pub, err := parseRSAKey(k)
+		if err != nil {
+			continue // Lewati kunci yang rusak, jangan gagalkan seluruh set
+		}
+		keys[k.Kid] = pub
+	}
+	p.keys = &keySet{keys: keys, fetchedAt: p.now()}
+
+	if k, ok := keys[kid]; ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidIDToken, kid)
+}
+
+func parseRSAKey(k jwk) (*rsa.PublicKey, error) {
+	nb, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+	eb, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil || len(eb) == 0 || len(eb) > 4 {
+		return nil, errors.New("invalid exponent")
+	}
+	e := 0
+	for _, b := range eb {
+		e = e<<8 | int(b)
+	}
+	n := new(big.Int).SetBytes(nb)
+	if n.BitLen() < minRSAKeyBits || n.BitLen() > maxRSAKeyBits || e < 3 {
+		return nil, errors.New("unsupported key size")
+	}
+	return &rsa.PublicKey{N: n, E: e}, nil
+}
+
+// Verify memeriksa tanda tangan RS256, iss, aud/azp, exp/iat dan nonce ID token
+func (p *Provider) Verify(ctx context.Context, raw, nonce string) (*Claims, error) {
+	parts := strings.Split(raw, ".")
+	if len(parts) != 3 {
+		return nil, ErrInvalidIDToken
+	}
+
+	var header struct {
+		Alg string `json:"alg"`
+		Kid string `json:"kid"`
+	}
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	// Hanya RS256; "none" dan HS256 (dengan public key sebagai secret) ditolak
+	if header.Alg != "RS256" {
+		return nil, fmt.Errorf("%w: unsupported alg %q", ErrInvalidIDToken, header.Alg)
+	}
+	pub, err := p.key(ctx, header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	sig, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], sig); err != nil {
+		return nil, fmt.Errorf("%w: bad signature", ErrInvalidIDToken)
+	}
+
+	var rawClaims map[string]interface{}
+	if err := decodeSegment(parts[1], &rawClaims); err != nil {
+		return nil, ErrInvalidIDToken
+	}
+	if err := p.validateClaims(rawClaims, nonce); err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{Raw: rawClaims}
+	claims.Subject, _ = rawClaims["sub"].(string)
+	claims.Email, _ = rawClaims["email"].(string)
+	claims.PreferredUsername, _ = rawClaims["preferred_username"].(string)
+	claims.Name, _ = rawClaims["name"].(string)
+	switch v := rawClaims["email_verified"].(type) {
+	case bool:
+		claims.EmailVerified = v
+	case string: // Beberapa provider mengirimnya sebagai string
+		claims.EmailVerified = v == "true"
+	}
+	if claims.Subject == "" {
+		return nil, fmt.Errorf("%w: missing sub", ErrInvalidIDToken)
+	}
+	return claims, nil
+}
+
+func (p *Provider) validateClaims(c map[string]interface{}, nonce string) error {
+	if iss, _ := c["iss"].(string); strings.TrimRight(iss, "/") != p.Config.Issuer {
+		return fmt.Errorf("%w: issuer mismatch", ErrInvalidIDToken)
+	}
+
+	var aud []string
+	switch v := c["aud"].(type) {
+	case string:
+		aud = []string{v}
+	case []interface{}:
+		for _, a := range v {
+			if s, ok := a.(string); ok {
+				aud = append(aud, s)
+			}
+		}
+	}
+	found := false
+	for _, a := range aud {
+		if a == p.Config.ClientID {
+			found = true
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: audience mismatch", ErrInvalidIDToken)
+	}
+	if azp, ok := c["azp"].(string); ok && azp != p.Config.ClientID {
+		return fmt.Errorf("%w: authorized party mismatch", ErrInvalidIDToken)
+	}
+
+	now := p.now()
+	exp, ok := numericClaim(c, "exp")
+	if !ok || now.After(time.Unix(exp, 0).Add(clockSkew)) {
+		return fmt.Errorf("%w: expired", ErrInvalidIDToken)
+	}
+	if iat, ok := numericClaim(c, "iat"); ok && time.Unix(iat, 0).After(now.Add(clockSkew)) {
+		return fmt.Errorf("%w: issued in the future", ErrInvalidIDToken)
+	}
+	if nbf, ok := numericClaim(c, "nbf"); ok && time.Unix(nbf, 0).After(now.Add(clockSkew)) {
+		return fmt.Errorf("%w: not yet valid", ErrInvalidIDToken)
+	}
+
+	got, _ := c["nonce"].(string)
+	if nonce == "" || subtle.ConstantTimeCompare([]byte(got), []byte(nonce)) != 1 {
+		return fmt.Errorf("%w: nonce mismatch", ErrInvalidIDToken)
+	}
+	return nil
+}
+
+func numericClaim(c map[string]interface{}, name string) (int64, bool) {
+	v, ok := c[name].(float64)
+	if !ok {
+		return 0, false
+	}
+	return int64(v), true
+}
+
+func decodeSegment(seg string, v interface{}) error {
+	b, err := base64.RawURLEncoding.DecodeString(seg)
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(b, v)
+}
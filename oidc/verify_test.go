@@ -0,0 +1,344 @@
+package oidc
+
+import (
+	"context"
+	"crypto"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+const (
+	testClientID = "filoti"
+	testNonce    = "nonce-123"
+)
+
+// testIdP adalah provider OIDC tiruan: discovery, JWKS dan token endpoint di satu httptest.Server
+type testIdP struct {
+	server *httptest.Server
+
+	mu          sync.Mutex
+	keys        map[string]*rsa.PrivateKey // kid -> kunci yang dipublikasikan di JWKS
+	idToken     string
+	tokenForm   map[string]string
+	basicUser   string
+	basicPass   string
+	jwksFetches int
+}
+
+var (
+	keyOnce sync.Once
+	keyA    *rsa.PrivateKey
+	keyB    *rsa.PrivateKey
+)
+
+func testKeys(t *testing.T) (*rsa.PrivateKey, *rsa.PrivateKey) {
+	t.Helper()
+	keyOnce.Do(func() {
+		var err error
+		if keyA, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			panic(err)
+		}
+		if keyB, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			panic(err)
+		}
+	})
+	return keyA, keyB
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	t.Helper()
+	a, _ := testKeys(t)
+	idp := &testIdP{keys: map[string]*rsa.PrivateKey{"k1": a}}
+	mux := http.NewServeMux()
+	discovery := func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(map[string]string{
+			"issuer":                 idp.server.URL,
+			"authorization_endpoint": idp.server.URL + "/authorize",
+			"token_endpoint":         idp.server.URL + "/token",
+			"jwks_uri":               idp.server.URL + "/jwks",
+		})
+	}
+	mux.HandleFunc("/.well-known/openid-configuration", discovery)
+	mux.HandleFunc("/realms/other/.well-known/openid-configuration", discovery)
+	mux.HandleFunc("/jwks", func(w http.ResponseWriter, r *http.Request) {
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		idp.jwksFetches++
+		var keys []map[string]string
+		for kid, k := range idp.keys {
+			keys = append(keys, map[string]string{
+				"kty": "RSA", "kid": kid, "use": "sig", "alg": "RS256",
+				"n": base64.RawURLEncoding.EncodeToString(k.N.Bytes()),
+				"e": base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+			})
+		}
+		json.NewEncoder(w).Encode(map[string]interface{}{"keys": keys})
+	})
+	mux.HandleFunc("/token", func(w http.ResponseWriter, r *http.Request) {
+		if err := r.ParseForm(); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		idp.mu.Lock()
+		defer idp.mu.Unlock()
+		idp.tokenForm = map[string]string{}
+		for k := range r.PostForm {
+			idp.tokenForm[k] = r.PostForm.Get(k)
+		}
+		idp.basicUser, idp.basicPass, _ = r.BasicAuth()
+		w.Header().Set("Content-Type", "application/json")
+		if r.PostForm.Get("code") != "good-code" {
+			w.WriteHeader(http.StatusBadRequest)
+			json.NewEncoder(w).Encode(map[string]string{"error": "invalid_grant", "error_description": "bad code"})
+			return
+		}
+		json.NewEncoder(w).Encode(map[string]string{"access_token": "at", "token_type": "Bearer", "id_token": idp.idToken})
+	})
+	idp.server = httptest.NewServer(mux)
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *testIdP) provider(now time.Time) *Provider {
+	p := New(Config{
+		Name:         "test",
+		Issuer:       idp.server.URL + "/",
+		ClientID:     testClientID,
+		ClientSecret: "s3cret&more",
+		RedirectURL:  "https://app.example/auth/oidc/callback",
+	})
+	p.now = func() time.Time { return now }
+	return p
+}
+
+func (idp *testIdP) claims(now time.Time) map[string]interface{} {
+	return map[string]interface{}{
+		"iss":            idp.server.URL,
+		"aud":            testClientID,
+		"sub":            "user-1",
+		"email":          "budi@example.ac.id",
+		"email_verified": true,
+		"nonce":          testNonce,
+		"iat":            now.Unix(),
+		"exp":            now.Add(5 * time.Minute).Unix(),
+		"groups":         []string{"staff", "lostfound"},
+	}
+}
+
+func segment(t *testing.T, v interface{}) string {
+	t.Helper()
+	b, err := json.Marshal(v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// signRS256 membuat ID token RS256 dengan kid tertentu
+func signRS256(t *testing.T, key *rsa.PrivateKey, kid string, claims map[string]interface{}) string {
+	t.Helper()
+	input := segment(t, map[string]string{"alg": "RS256", "typ": "JWT", "kid": kid}) + "." + segment(t, claims)
+	digest := sha256.Sum256([]byte(input))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return input + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func TestVerify(t *testing.T) {
+	idp := newTestIdP(t)
+	a, b := testKeys(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	with := func(edit func(map[string]interface{})) map[string]interface{} {
+		c := idp.claims(now)
+		edit(c)
+		return c
+	}
+	valid := signRS256(t, a, "k1", idp.claims(now))
+
+	// HS256 dengan public key sebagai secret (key confusion) harus ditolak
+	pubDER, err := x509.MarshalPKIXPublicKey(&a.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	hsInput := segment(t, map[string]string{"alg": "HS256", "kid": "k1"}) + "." + segment(t, idp.claims(now))
+	mac := hmac.New(sha256.New, pubDER)
+	mac.Write([]byte(hsInput))
+	hsToken := hsInput + "." + base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+
+	noneToken := segment(t, map[string]string{"alg": "none", "kid": "k1"}) + "." + segment(t, idp.claims(now)) + "."
+
+	tamperedClaims := segment(t, with(func(c map[string]interface{}) { c["sub"] = "admin" }))
+	parts := strings.Split(valid, ".")
+	tampered := parts[0] + "." + tamperedClaims + "." + parts[2]
+
+	tests := []struct {
+		name  string
+		token string
+		nonce string
+		ok    bool
+	}{
+		{"valid", valid, testNonce, true},
+		{"audience array", signRS256(t, a, "k1", with(func(c map[string]interface{}) { c["aud"] = []string{"other", testClientID} })), testNonce, true},
+		{"wrong issuer", signRS256(t, a, "k1", with(func(c map[string]interface{}) { c["iss"] = "https://evil.example" })), testNonce, false},
+		{"wrong audience", signRS256(t, a, "k1", with(func(c map[string]interface{}) { c["aud"] = "other-client" })), testNonce, false},
+		{"missing audience", signRS256(t, a, "k1", with(func(c map[string]interface{}) { delete(c, "aud") })), testNonce, false},
+		{"wrong azp", signRS256(t, a, "k1", with(func(c map[string]interface{}) { c["azp"] = "other-client" })), testNonce, false},
+		{"wrong nonce", valid, "another-nonce", false},
+		{"missing nonce claim", signRS256(t, a, "k1", with(func(c map[string]interface{}) { delete(c, "nonce") })), testNonce, false},
+		{"empty expected nonce", signRS256(t, a, "k1", with(func(c map[string]interface{}) { c["nonce"] = "" })), "", false},
+		{"expired", signRS256(t, a, "k1", with(func(c map[string]interface{}) { c["exp"] = now.Add(-2 * time.Minute).Unix() })), testNonce, false},
+		{"missing exp", signRS256(t, a, "k1", with(func(c map[string]interface{}) { delete(c, "exp") })), testNonce, false},
+		{"issued in the future", signRS256(t, a, "k1", with(func(c map[string]interface{}) { c["iat"] = now.Add(time.Hour).Unix() })), testNonce, false},
+		{"not yet valid", signRS256(t, a, "k1", with(func(c map[string]interface{}) { c["nbf"] = now.Add(time.Hour).Unix() })), testNonce, false},
+		{"missing sub", signRS256(t, a, "k1", with(func(c map[string]interface{}) { delete(c, "sub") })), testNonce, false},
+		{"unknown kid", signRS256(t, a, "k2", idp.claims(now)), testNonce, false},
+		{"signed by another key", signRS256(t, b, "k1", idp.claims(now)), testNonce, false},
+		{"tampered payload", tampered, testNonce, false},
+		{"alg none", noneToken, testNonce, false},
+		{"alg HS256 with public key", hsToken, testNonce, false},
+		{"malformed", "not-a-jwt", testNonce, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			p := idp.provider(now)
+			claims, err := p.Verify(context.Background(), tt.token, tt.nonce)
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("Verify accepted the token")
+				}
+				if !errors.Is(err, ErrInvalidIDToken) {
+					t.Errorf("error = %v, want ErrInvalidIDToken", err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Verify: %v", err)
+			}
+			if claims.Subject != "user-1" || claims.Email != "budi@example.ac.id" || !claims.EmailVerified {
+				t.Errorf("claims = %+v", claims)
+			}
+			if g := claims.Groups("groups"); len(g) != 2 || g[1] != "lostfound" {
+				t.Errorf("Groups = %v", g)
+			}
+		})
+	}
+}
+
+func TestVerifyRefetchesJWKSOnKeyRotation(t *testing.T) {
+	idp := newTestIdP(t)
+	a, b := testKeys(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+	p := idp.provider(now)
+
+	if _, err := p.Verify(context.Background(), signRS256(t, a, "k1", idp.claims(now)), testNonce); err != nil {
+		t.Fatal(err)
+	}
+
+	idp.mu.Lock()
+	idp.keys = map[string]*rsa.PrivateKey{"k2": b}
+	idp.mu.Unlock()
+	rotated := signRS256(t, b, "k2", idp.claims(now))
+
+	// Dalam jendela jwksMinRefresh kid baru belum diambil agar kid acak tidak membanjiri provider
+	if _, err := p.Verify(context.Background(), rotated, testNonce); err == nil {
+		t.Fatal("unknown kid accepted before the refresh interval")
+	}
+	later := now.Add(jwksMinRefresh + time.Second)
+	p.now = func() time.Time { return later }
+	if _, err := p.Verify(context.Background(), signRS256(t, b, "k2", idp.claims(later)), testNonce); err != nil {
+		t.Fatalf("Verify after rotation: %v", err)
+	}
+	if idp.jwksFetches != 2 {
+		t.Errorf("jwks fetched %d times, want 2", idp.jwksFetches)
+	}
+}
+
+func TestExchange(t *testing.T) {
+	idp := newTestIdP(t)
+	a, _ := testKeys(t)
+	now := time.Date(2024, 3, 1, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		code    string
+		idToken string
+		ok      bool
+	}{
+		{"valid", "good-code", signRS256(t, a, "k1", idp.claims(now)), true},
+		{"rejected code", "bad-code", signRS256(t, a, "k1", idp.claims(now)), false},
+		{"wrong issuer in id token", "good-code", signRS256(t, a, "k1", func() map[string]interface{} {
+			c := idp.claims(now)
+			c["iss"] = "https://evil.example"
+			return c
+		}()), false},
+		{"missing id token", "good-code", "", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			idp.mu.Lock()
+			idp.idToken = tt.idToken
+			idp.mu.Unlock()
+
+			p := idp.provider(now)
+			claims, err := p.Exchange(context.Background(), tt.code, "verifier-xyz", testNonce)
+			if !tt.ok {
+				if err == nil {
+					t.Fatal("Exchange succeeded")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+			if claims.Subject != "user-1" {
+				t.Errorf("sub = %q", claims.Subject)
+			}
+
+			idp.mu.Lock()
+			defer idp.mu.Unlock()
+			want := map[string]string{
+				"grant_type":    "authorization_code",
+				"code":          "good-code",
+				"code_verifier": "verifier-xyz",
+				"redirect_uri":  "https://app.example/auth/oidc/callback",
+			}
+			for k, v := range want {
+				if idp.tokenForm[k] != v {
+					t.Errorf("token form %s = %q, want %q", k, idp.tokenForm[k], v)
+				}
+			}
+			if _, ok := idp.tokenForm["client_id"]; ok {
+				t.Error("confidential client sent client_id in the form")
+			}
+			// client_secret_basic meng-URL-encode id dan secret sebelum base64
+			if idp.basicUser != testClientID || idp.basicPass != "s3cret%26more" {
+				t.Errorf("basic auth = %q:%q", idp.basicUser, idp.basicPass)
+			}
+		})
+	}
+}
+
+func TestDiscoveryIssuerMismatch(t *testing.T) {
+	idp := newTestIdP(t)
+	// /realms/other menyajikan dokumen discovery milik issuer root server
+	p := New(Config{Name: "test", Issuer: idp.server.URL + "/realms/other", ClientID: testClientID})
+	if _, err := p.AuthCodeURL(context.Background(), "state", testNonce, "verifier"); err == nil {
+		t.Fatal("AuthCodeURL accepted a discovery document for another issuer")
+	}
+}
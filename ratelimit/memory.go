@@ -0,0 +1,71 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// Memory menyimpan counter di memori proses. Hanya cocok untuk satu instance
+// (server lokal); instance Vercel tidak berbagi memori, gunakan Postgres di sana.
+type Memory struct {
+	mu        sync.Mutex
+	entries   map[string]Entry
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+func NewMemory() *Memory {
+	return &Memory{entries: make(map[string]Entry), lastSweep: time.Now(), now: time.Now}
+}
+
+func (m *Memory) Incr(_ context.Context, key string, window time.Duration) (Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := m.now()
+	m.sweep(now)
+	e := m.entries[key]
+	if !e.ResetAt.After(now) {
+		e.Count = 0
+		e.ResetAt = now.Add(window)
+	}
+	e.Count++
+	m.entries[key] = e
+	return e, nil
+}
+
+func (m *Memory) Get(_ context.Context, key string) (Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.entries[key], nil
+}
+
+func (m *Memory) Lock(_ context.Context, key string, until time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e := m.entries[key]
+	e.LockedUntil = until
+	m.entries[key] = e
+	return nil
+}
+
+func (m *Memory) Reset(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.entries, key)
+	return nil
+}
+
+// sweep membuang entry yang window dan lock-nya sudah lewat, paling sering sekali per menit
+func (m *Memory) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) < time.Minute {
+		return
+	}
+	m.lastSweep = now
+	for key, e := range m.entries {
+		if !e.ResetAt.After(now) && !e.LockedUntil.After(now) {
+			delete(m.entries, key)
+		}
+	}
+}
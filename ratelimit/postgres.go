@@ -0,0 +1,75 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"math/rand"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Postgres menyimpan counter di tabel rate_limits (models.RateLimit) sehingga
+// semua instance serverless berbagi limit yang sama
+type Postgres struct {
+	db *gorm.DB
+}
+
+func NewPostgres(db *gorm.DB) *Postgres {
+	return &Postgres{db: db}
+}
+
+type row struct {
+	Count       int
+	ResetAt     time.Time
+	LockedUntil sql.NullTime
+}
+
+func (r row) entry() Entry {
+	e := Entry{Count: r.Count, ResetAt: r.ResetAt}
+	if r.LockedUntil.Valid {
+		e.LockedUntil = r.LockedUntil.Time
+	}
+	return e
+}
+
+func (p *Postgres) Incr(ctx context.Context, key string, window time.Duration) (Entry, error) {
+	now := time.Now()
+	var r row
+	err := p.db.WithContext(ctx).Raw(`INSERT INTO rate_limits (key, count, reset_at) VALUES (@key, 1, @reset)
+		ON CONFLICT (key) DO UPDATE SET
+			count = CASE WHEN rate_limits.reset_at <= @now THEN 1 ELSE rate_limits.count + 1 END,
+			reset_at = CASE WHEN rate_limits.reset_at <= @now THEN EXCLUDED.reset_at ELSE rate_limits.reset_at END
+		RETURNING count, reset_at, locked_until`,
+		sql.Named("key", key), sql.Named("reset", now.Add(window)), sql.Named("now", now)).
+		Scan(&r).Error
+	if err != nil {
+		return Entry{}, err
+	}
+	// Bersihkan baris kedaluwarsa sesekali; tidak perlu cron terpisah
+	if rand.Intn(100) == 0 {
+		p.db.WithContext(ctx).Exec(`DELETE FROM rate_limits
+			WHERE reset_at < ? AND (locked_until IS NULL OR locked_until < ?)`, now, now)
+	}
+	return r.entry(), nil
+}
+
+func (p *Postgres) Get(ctx context.Context, key string) (Entry, error) {
+	var rows []row
+	if err := p.db.WithContext(ctx).Raw(`SELECT count, reset_at, locked_until FROM rate_limits WHERE key = ?`, key).
+		Scan(&rows).Error; err != nil {
+		return Entry{}, err
+	}
+	if len(rows) == 0 {
+		return Entry{}, nil
+	}
+	return rows[0].entry(), nil
+}
+
+func (p *Postgres) Lock(ctx context.Context, key string, until time.Time) error {
+	return p.db.WithContext(ctx).Exec(`UPDATE rate_limits SET locked_until = ? WHERE key = ?`, until, key).Error
+}
+
+func (p *Postgres) Reset(ctx context.Context, key string) error {
+	return p.db.WithContext(ctx).Exec(`DELETE FROM rate_limits WHERE key = ?`, key).Error
+}
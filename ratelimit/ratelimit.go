@@ -0,0 +1,115 @@
+// Package ratelimit membatasi laju request (fixed window) dan memberi lockout dengan
+// exponential backoff setelah kegagalan berulang, misalnya login dengan password salah.
+// Counter disimpan di memori (satu instance) atau PostgreSQL (deployment serverless).
+package ratelimit
+
+import (
+	"context"
+	"time"
+)
+
+// Entry adalah keadaan satu counter
+type Entry struct {
+	Count       int
+	ResetAt     time.Time // Akhir window counter saat ini
+	LockedUntil time.Time // Zero jika tidak sedang dikunci
+}
+
+// Store menyimpan counter per key. Implementasi harus aman dipakai bersamaan.
+type Store interface {
+	// Incr menambah counter key secara atomik. Jika window sudah lewat, counter mulai lagi
+	// dari 1 dengan window baru. Lock yang masih aktif tidak ikut direset.
+	Incr(ctx context.Context, key string, window time.Duration) (Entry, error)
+	// Get mengembalikan Entry kosong jika key belum ada
+	Get(ctx context.Context, key string) (Entry, error)
+	Lock(ctx context.Context, key string, until time.Time) error
+	Reset(ctx context.Context, key string) error
+}
+
+// Rule membatasi Limit request per Window
+type Rule struct {
+	Limit  int
+	Window time.Duration
+}
+
+// Backoff mengunci key setelah Threshold kegagalan dalam Window. Lock pertama selama Base,
+// lalu berlipat dua untuk setiap kegagalan berikutnya sampai Max.
+type Backoff struct {
+	Threshold int
+	Window    time.Duration
+	Base      time.Duration
+	Max       time.Duration
+}
+
+// Delay menghitung lama lock setelah kegagalan ke-failures; 0 jika belum mencapai threshold
+func (b Backoff) Delay(failures int) time.Duration {
+	if failures < b.Threshold {
+		return 0
+	}
+	d := b.Base
+	for i := b.Threshold; i < failures && d < b.Max; i++ {
+		d *= 2
+	}
+	if d > b.Max {
+		d = b.Max
+	}
+	return d
+}
+
+// Limiter menerapkan Rule dan Backoff di atas sebuah Store
+type Limiter struct {
+	store Store
+	now   func() time.Time
+}
+
+func New(store Store) *Limiter {
+	return &Limiter{store: store, now: time.Now}
+}
+
+// Allow mencatat satu request untuk key. Hasilnya 0 jika diizinkan, atau lama tunggu sebelum
+// window berikutnya jika limit sudah terlampaui.
+func (l *Limiter) Allow(ctx context.Context, key string, rule Rule) (time.Duration, error) {
+	e, err := l.store.Incr(ctx, "rate:"+key, rule.Window)
+	if err != nil {
+		return 0, err
+	}
+	if e.Count <= rule.Limit {
+		return 0, nil
+	}
+	return wait(l.now(), e.ResetAt), nil
+}
+
+// Locked mengembalikan sisa waktu lock key, atau 0 jika tidak dikunci
+func (l *Limiter) Locked(ctx context.Context, key string) (time.Duration, error) {
+	e, err := l.store.Get(ctx, "fail:"+key)
+	if err != nil {
+		return 0, err
+	}
+	return wait(l.now(), e.LockedUntil), nil
+}
+
+// Fail mencatat satu kegagalan untuk key dan mengunci key sesuai Backoff.
+// Hasilnya lama lock yang baru diterapkan, atau 0 jika belum mencapai threshold.
+func (l *Limiter) Fail(ctx context.Context, key string, b Backoff) (time.Duration, error) {
+	e, err := l.store.Incr(ctx, "fail:"+key, b.Window)
+	if err != nil {
+		return 0, err
+	}
+	d := b.Delay(e.Count)
+	if d == 0 {
+		return 0, nil
+	}
+	return d, l.store.Lock(ctx, "fail:"+key, l.now().Add(d))
+}
+
+// Succeed menghapus riwayat kegagalan key, misalnya setelah login berhasil
+func (l *Limiter) Succeed(ctx context.Context, key string) error {
+	return l.store.Reset(ctx, "fail:"+key)
+}
+
+func wait(now, until time.Time) time.Duration {
+	if until.IsZero() || !until.After(now) {
+		return 0
+	}
+	return until.Sub(now)
+}
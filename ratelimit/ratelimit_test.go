@@ -0,0 +1,183 @@
+package ratelimit
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// clock adalah waktu palsu yang dipakai bersama oleh Memory dan Limiter di test
+type clock struct{ t time.Time }
+
+func (c *clock) now() time.Time          { return c.t }
+func (c *clock) advance(d time.Duration) { c.t = c.t.Add(d) }
+
+func newTestLimiter() (*Limiter, *Memory, *clock) {
+	clk := &clock{t: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)}
+	mem := NewMemory()
+	mem.now = clk.now
+	mem.lastSweep = clk.t
+	l := New(mem)
+	l.now = clk.now
+	return l, mem, clk
+}
+
+func TestBackoffDelay(t *testing.T) {
+	b := Backoff{Threshold: 5, Window: 15 * time.Minute, Base: time.Minute, Max: 10 * time.Minute}
+	tests := []struct {
+		failures int
+		want     time.Duration
+	}{
+		{0, 0},
+		{4, 0},
+		{5, time.Minute},
+		{6, 2 * time.Minute},
+		{7, 4 * time.Minute},
+		{8, 8 * time.Minute},
+		{9, 10 * time.Minute}, // 16 menit dipotong ke Max
+		{50, 10 * time.Minute},
+	}
+	for _, tt := range tests {
+		if got := b.Delay(tt.failures); got != tt.want {
+			t.Errorf("Delay(%d) = %v, want %v", tt.failures, got, tt.want)
+		}
+	}
+}
+
+func TestBackoffDelayBaseAboveMax(t *testing.T) {
+	b := Backoff{Threshold: 1, Base: time.Hour, Max: time.Minute}
+	if got := b.Delay(1); got != time.Minute {
+		t.Errorf("Delay(1) = %v, want %v", got, time.Minute)
+	}
+}
+
+func TestLimiterAllowWindowReset(t *testing.T) {
+	ctx := context.Background()
+	l, _, clk := newTestLimiter()
+	rule := Rule{Limit: 3, Window: time.Minute}
+
+	steps := []struct {
+		name    string
+		advance time.Duration
+		want    time.Duration
+	}{
+		{"first", 0, 0},
+		{"second", 10 * time.Second, 0},
+		{"third", 10 * time.Second, 0},
+		{"over limit waits for window end", 10 * time.Second, 30 * time.Second},
+		{"still over limit", 20 * time.Second, 10 * time.Second},
+		{"window reset", 10 * time.Second, 0},
+		{"new window second", 0, 0},
+	}
+	for _, s := range steps {
+		clk.advance(s.advance)
+		got, err := l.Allow(ctx, "ip", rule)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != s.want {
+			t.Errorf("%s: Allow = %v, want %v", s.name, got, s.want)
+		}
+	}
+
+	// Key lain punya counter sendiri
+	if got, _ := l.Allow(ctx, "other", rule); got != 0 {
+		t.Errorf("Allow(other) = %v, want 0", got)
+	}
+}
+
+func TestLimiterFailLockAndSucceed(t *testing.T) {
+	ctx := context.Background()
+	l, _, clk := newTestLimiter()
+	b := Backoff{Threshold: 3, Window: 15 * time.Minute, Base: time.Minute, Max: 4 * time.Minute}
+
+	wantDelays := []time.Duration{0, 0, time.Minute, 2 * time.Minute, 4 * time.Minute, 4 * time.Minute}
+	for i, want := range wantDelays {
+		got, err := l.Fail(ctx, "alice", b)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != want {
+			t.Errorf("Fail #%d = %v, want %v", i+1, got, want)
+		}
+	}
+	if got, _ := l.Locked(ctx, "alice"); got != 4*time.Minute {
+		t.Errorf("Locked = %v, want %v", got, 4*time.Minute)
+	}
+
+	clk.advance(3 * time.Minute)
+	if got, _ := l.Locked(ctx, "alice"); got != time.Minute {
+		t.Errorf("Locked after 3m = %v, want %v", got, time.Minute)
+	}
+	clk.advance(time.Minute)
+	if got, _ := l.Locked(ctx, "alice"); got != 0 {
+		t.Errorf("Locked after expiry = %v, want 0", got)
+	}
+
+	if err := l.Succeed(ctx, "alice"); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := l.Fail(ctx, "alice", b); got != 0 {
+		t.Errorf("Fail after Succeed = %v, want 0 (counter reset)", got)
+	}
+}
+
+func TestLimiterFailWindowReset(t *testing.T) {
+	ctx := context.Background()
+	l, _, clk := newTestLimiter()
+	b := Backoff{Threshold: 2, Window: time.Minute, Base: time.Minute, Max: time.Hour}
+
+	if got, _ := l.Fail(ctx, "bob", b); got != 0 {
+		t.Fatalf("first Fail = %v, want 0", got)
+	}
+	// Kegagalan di luar window tidak dihitung bersama yang sebelumnya
+	clk.advance(time.Minute)
+	if got, _ := l.Fail(ctx, "bob", b); got != 0 {
+		t.Errorf("Fail after window = %v, want 0", got)
+	}
+	if got, _ := l.Fail(ctx, "bob", b); got != time.Minute {
+		t.Errorf("second Fail in new window = %v, want %v", got, time.Minute)
+	}
+}
+
+func TestMemoryIncrKeepsLockAcrossWindows(t *testing.T) {
+	ctx := context.Background()
+	_, mem, clk := newTestLimiter()
+	until := clk.t.Add(time.Hour)
+
+	if _, err := mem.Incr(ctx, "k", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	if err := mem.Lock(ctx, "k", until); err != nil {
+		t.Fatal(err)
+	}
+	clk.advance(2 * time.Minute)
+	e, err := mem.Incr(ctx, "k", time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if e.Count != 1 || !e.ResetAt.Equal(clk.t.Add(time.Minute)) {
+		t.Errorf("Incr after window = %+v, want count 1 and new window", e)
+	}
+	if !e.LockedUntil.Equal(until) {
+		t.Errorf("LockedUntil = %v, want %v", e.LockedUntil, until)
+	}
+}
+
+func TestMemorySweep(t *testing.T) {
+	ctx := context.Background()
+	_, mem, clk := newTestLimiter()
+
+	mem.Incr(ctx, "expired", time.Second)
+	mem.Incr(ctx, "locked", time.Second)
+	mem.Lock(ctx, "locked", clk.t.Add(time.Hour))
+	clk.advance(2 * time.Minute)
+	mem.Incr(ctx, "trigger", time.Minute) // sweep berjalan paling sering sekali per menit
+
+	if _, ok := mem.entries["expired"]; ok {
+		t.Error("expired entry was not swept")
+	}
+	if _, ok := mem.entries["locked"]; !ok {
+		t.Error("locked entry was swept")
+	}
+}
@@ -0,0 +1,97 @@
+// Package realtime menyalurkan notifikasi baru ke koneksi SSE yang sedang terbuka.
+// Event masuk lewat PostgreSQL LISTEN/NOTIFY sehingga tetap sampai walaupun
+// notifikasi dibuat oleh instance aplikasi yang lain.
+package realtime
+
+import (
+	"sync"
+)
+
+// NotificationChannel adalah nama channel NOTIFY yang dipakai saat notifikasi di-commit
+const NotificationChannel = "notifications"
+
+// ResyncID dikirim ke semua subscriber setelah listener tersambung ulang: NOTIFY selama
+// koneksi terputus hilang, jadi subscriber harus membaca ulang notifikasi dari database.
+// ID notifikasi selalu dimulai dari 1 sehingga 0 tidak pernah bentrok.
+const ResyncID uint = 0
+
+// Hub menyimpan subscriber per user
+type Hub struct {
+	mu   sync.RWMutex
+	subs map[uint]map[chan uint]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{subs: make(map[uint]map[chan uint]struct{})}
+}
+
+// Subscribe mendaftarkan listener untuk userID. Channel menerima ID notifikasi baru;
+// panggil cancel saat koneksi ditutup. Channel ditutup oleh Hub jika buffernya penuh.
+func (h *Hub) Subscribe(userID uint) (<-chan uint, func()) {
+	ch := make(chan uint, 16)
+
+	h.mu.Lock()
+	if h.subs[userID] == nil {
+		h.subs[userID] = make(map[chan uint]struct{})
+	}
+	h.subs[userID][ch] = struct{}{}
+	h.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			h.mu.Lock()
+			delete(h.subs[userID], ch)
+			if len(h.subs[userID]) == 0 {
+				delete(h.subs, userID)
+			}
+			h.mu.Unlock()
+		})
+	}
+	return ch, cancel
+}
+
+// Publish mengirim ID notifikasi ke semua subscriber milik userID.
+// Channel subscriber yang terlalu lambat (buffer penuh) ditutup dan dilepas, sehingga
+// koneksi SSE-nya berakhir dan klien reconnect dengan Last-Event-ID untuk mengejar.
+func (h *Hub) Publish(userID, notificationID uint) {
+	var overflowed []chan uint
+	h.mu.RLock()
+	for ch := range h.subs[userID] {
+		select {
+		case ch <- notificationID:
+		default:
+			overflowed = append(overflowed, ch)
+		}
+	}
+	h.mu.RUnlock()
+	if len(overflowed) == 0 {
+		return
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for _, ch := range overflowed {
+		if _, ok := h.subs[userID][ch]; !ok {
+			continue // Sudah di-cancel atau ditutup oleh Publish lain
+		}
+		delete(h.subs[userID], ch)
+		close(ch)
+	}
+	if len(h.subs[userID]) == 0 {
+		delete(h.subs, userID)
+	}
+}
+
+// Resync mengirim ResyncID ke semua subscriber
+func (h *Hub) Resync() {
+	h.mu.RLock()
+	users := make([]uint, 0, len(h.subs))
+	for userID := range h.subs {
+		users = append(users, userID)
+	}
+	h.mu.RUnlock()
+	for _, userID := range users {
+		h.Publish(userID, ResyncID)
+	}
+}
@@ -0,0 +1,75 @@
+package realtime
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Default adalah hub yang dipakai handler SSE
+var Default = NewHub()
+
+var startOnce sync.Once
+
+// notifyPayload adalah isi pg_notify yang dikirim saat notifikasi dibuat
+type notifyPayload struct {
+	ID     uint `json:"id"`
+	UserID uint `json:"user_id"`
+}
+
+// EnsureListening menjalankan listener LISTEN/NOTIFY sekali per instance.
+// Listener baru dibuka saat ada klien SSE pertama, supaya instance yang
+// tidak melayani stream tidak memegang koneksi database tambahan.
+func EnsureListening(dsn string) {
+	startOnce.Do(func() {
+		go listenLoop(context.Background(), dsn, Default)
+	})
+}
+
+// listenLoop terus mencoba (re)connect dengan backoff jika koneksi terputus
+func listenLoop(ctx context.Context, dsn string, hub *Hub) {
+	backoff := time.Second
+	for {
+		err := listen(ctx, dsn, hub)
+		if ctx.Err() != nil {
+			return
+		}
+		log.Printf("realtime: listener stopped - %v (retrying in %s)", err, backoff)
+		time.Sleep(backoff)
+		if backoff < 30*time.Second {
+			backoff *= 2
+		}
+	}
+}
+
+func listen(ctx context.Context, dsn string, hub *Hub) error {
+	conn, err := pgx.Connect(ctx, dsn)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+pgx.Identifier{NotificationChannel}.Sanitize()); err != nil {
+		return err
+	}
+	log.Printf("realtime: listening on channel %q", NotificationChannel)
+	// Notifikasi yang dibuat sebelum LISTEN aktif (termasuk selama reconnect) tidak pernah diterima
+	hub.Resync()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+		var payload notifyPayload
+		if err := json.Unmarshal([]byte(n.Payload), &payload); err != nil {
+			log.Printf("realtime: invalid payload %q - %v", n.Payload, err)
+			continue
+		}
+		hub.Publish(payload.UserID, payload.ID)
+	}
+}
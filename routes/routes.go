@@ -5,23 +5,31 @@ import (
 	"os"
 	"time"
 
+	"filoti-backend/config"
 	"filoti-backend/controllers"
 	"filoti-backend/middleware" // Menggunakan 'middleware' sesuai dengan kode Anda
+	"filoti-backend/models"
+	"filoti-backend/sessionstore"
+	"filoti-backend/storage"
 
 	"github.com/gin-contrib/cors"
 	"github.com/gin-contrib/sessions"
-	"github.com/gin-contrib/sessions/cookie"
 	"github.com/gin-gonic/gin"
 )
 
 func SetupRouter() *gin.Engine {
 	r := gin.Default()
+	// c.ClientIP() (rate limit, audit log) hanya membaca header dari platform hosting, bukan X-Forwarded-For
+	r.TrustedPlatform = config.TrustedPlatformHeader()
+	r.SetTrustedProxies(nil)
 
 	sessionSecret := os.Getenv("SESSION_SECRET")
 	if sessionSecret == "" {
 		sessionSecret = "secret"
 	}
-	store := cookie.NewStore([]byte(sessionSecret))
+	// Session disimpan di PostgreSQL agar bisa didaftar dan di-revoke (lihat /me/sessions)
+	store := sessionstore.New(config.DB, []byte(sessionSecret))
+	store.TrustedPlatform(r.TrustedPlatform)
 	store.Options(sessions.Options{
 		Path:     "/",
 		MaxAge:   3600 * 24,
@@ -40,8 +48,8 @@ func SetupRouter() *gin.Engine {
 			"https://filoti-frontend.vercel.app",
 		},
 		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Accept", "Authorization", "Last-Event-ID", "If-Match", "If-None-Match"},
+		ExposeHeaders:    []string{"Content-Length", "ETag", "Retry-After"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
@@ -54,10 +62,30 @@ func SetupRouter() *gin.Engine {
 	// --- Public Routes (Dapat diakses tanpa autentikasi) ---
 	r.POST("/signup", controllers.Signup)
 	r.POST("/login", controllers.Login)
+	r.POST("/login/2fa", controllers.LoginTwoFactor) // Langkah kedua untuk akun dengan 2FA aktif
 	r.POST("/guest-login", controllers.GuestLogin)
+	r.POST("/auth/token", controllers.IssueToken)   // Access/refresh token untuk aplikasi mobile & script
+	r.POST("/auth/revoke", controllers.RevokeToken) // Revoke refresh token (logout klien token)
+	// SSO kampus (OpenID Connect), provider dikonfigurasi lewat OIDC_PROVIDERS
+	r.GET("/auth/oidc/providers", controllers.GetOIDCProviders)
+	r.GET("/auth/oidc/login", controllers.OIDCLogin)
+	r.GET("/auth/oidc/callback", controllers.OIDCCallback)
+	r.POST("/password/forgot", controllers.ForgotPassword)
+	r.POST("/password/reset", controllers.ResetPassword)
+	r.POST("/email/verify", controllers.VerifyEmail)
 	r.GET("/locations", controllers.GetUniqueLocations)
-	r.GET("/posts", controllers.GetPosts)        // Postingan dapat dilihat oleh siapa saja
-	r.GET("/posts/:id", controllers.GetPostByID) // Detail postingan dapat dilihat oleh siapa saja
+	r.GET("/cron/purge-trash", controllers.CronPurgeTrash) // Vercel Cron, dilindungi CRON_SECRET
+	r.GET("/posts", controllers.GetPosts)                  // Postingan dapat dilihat oleh siapa saja
+	r.GET("/posts/search", controllers.SearchPosts)        // Full-text search postingan
+	r.GET("/posts/:id", controllers.GetPostByID)           // Detail postingan dapat dilihat oleh siapa saja
+
+	// File upload disajikan langsung jika memakai storage lokal (S3 punya URL publik sendiri)
+	if local, ok := config.Storage.(*storage.Local); ok {
+		uploads := r.Group("/uploads", func(c *gin.Context) {
+			c.Header("Cache-Control", "public, max-age=31536000, immutable") // Key upload selalu unik
+		})
+		uploads.Static("/", local.Dir)
+	}
 
 	// --- Authenticated Routes (Memerlukan session yang valid) ---
 	authorized := r.Group("/")
@@ -66,15 +94,74 @@ func SetupRouter() *gin.Engine {
 		// Rute untuk user yang sedang login
 		authorized.GET("/me", controllers.GetCurrentUser)
 		authorized.POST("/logout", controllers.Logout)
+		authorized.POST("/me/password", controllers.ChangePassword)
+		authorized.POST("/me/email", controllers.RequestEmailVerification) // Kirim link verifikasi email
+		authorized.POST("/me/2fa/setup", controllers.SetupTwoFactor)
+		authorized.POST("/me/2fa/enable", controllers.EnableTwoFactor)
+		authorized.POST("/me/2fa/disable", controllers.DisableTwoFactor)
+		authorized.POST("/me/2fa/recovery-codes", controllers.RegenerateRecoveryCodes)
+		authorized.GET("/me/sessions", controllers.GetMySessions)
+		authorized.DELETE("/me/sessions/:id", controllers.DeleteMySession)
+		authorized.GET("/me/claims", controllers.GetMyClaims)
+		authorized.GET("/me/subscriptions", controllers.GetSubscriptions)
+		authorized.POST("/me/subscriptions", controllers.CreateSubscription)
+		authorized.PATCH("/me/subscriptions/:id", controllers.UpdateSubscription)
+		authorized.DELETE("/me/subscriptions/:id", controllers.DeleteSubscription)
 		authorized.GET("/notifications", controllers.GetNotifications) // Endpoint notifikasi
+		authorized.GET("/notifications/unread-count", controllers.GetUnreadNotificationCount)
+		authorized.GET("/notifications/stream", controllers.StreamNotifications) // Server-Sent Events
+		authorized.PATCH("/notifications/:id/read", controllers.MarkNotificationRead)
+		authorized.POST("/notifications/read-all", controllers.MarkAllNotificationsRead)
+		authorized.DELETE("/notifications/:id", controllers.DeleteNotification)
+		authorized.POST("/uploads", controllers.UploadImage) // Upload gambar post / bukti klaim
 
 		// Rute Post yang memerlukan autentikasi (dan cek isAdmin di controllernya)
 		posts := authorized.Group("/posts")
 		{
-			posts.POST("", controllers.CreatePost)             // Membuat post (memerlukan login)
-			posts.PUT("/:id", controllers.UpdatePost)          // Update post (memerlukan login & isAdmin)
-			posts.DELETE("/:id", controllers.DeletePost)       // Hapus post (memerlukan login & isAdmin)
-			posts.PUT("/:id/done", controllers.MarkPostAsDone) // Tandai selesai (memerlukan login & isAdmin)
+			posts.POST("", middleware.RequirePermission(models.PermPostsCreate), controllers.CreatePost)
+			posts.PUT("/:id", middleware.RequirePermission(models.PermPostsUpdate), controllers.UpdatePost)
+			posts.PATCH("/:id", middleware.RequirePermission(models.PermPostsUpdate), controllers.PatchPost) // JSON merge-patch
+			posts.DELETE("/:id", middleware.RequirePermission(models.PermPostsDelete), controllers.DeletePost)
+			posts.PUT("/:id/done", middleware.RequirePermission(models.PermPostsTransition), controllers.MarkPostAsDone)
+			posts.POST("/:id/transition", middleware.RequirePermission(models.PermPostsTransition), controllers.TransitionPost)
+			posts.POST("/:id/restore", middleware.RequirePermission(models.PermPostsRestore), controllers.RestorePost) // Pulihkan dari tempat sampah
+			posts.POST("/:id/claims", middleware.RequirePermission(models.PermClaimsCreate), controllers.CreateClaim)
+			posts.GET("/:id/history", controllers.GetPostHistory)
+			posts.GET("/:id/matches", middleware.RequirePermission(models.PermMatchesRead), controllers.GetPostMatches) // Saran laporan kehilangan yang cocok
+		}
+
+		// Laporan kehilangan dari mahasiswa
+		lostReports := authorized.Group("/lost-reports")
+		{
+			lostReports.POST("", middleware.RequirePermission(models.PermLostReportsCreate), controllers.CreateLostReport)
+			lostReports.GET("", controllers.GetLostReports)
+			lostReports.GET("/:id", controllers.GetLostReportByID)
+			lostReports.POST("/:id/close", controllers.CloseLostReport)
+			lostReports.GET("/:id/matches", controllers.GetLostReportMatches)
+		}
+
+		// Rute khusus admin; setiap rute dijaga oleh izinnya masing-masing
+		admin := authorized.Group("/admin")
+		{
+			admin.GET("/claims", middleware.RequirePermission(models.PermClaimsReview), controllers.GetClaimQueue)
+			admin.POST("/claims/:id/approve", middleware.RequirePermission(models.PermClaimsReview), controllers.ApproveClaim)
+			admin.POST("/claims/:id/reject", middleware.RequirePermission(models.PermClaimsReview), controllers.RejectClaim)
+			admin.POST("/lost-reports/:id/link", middleware.RequirePermission(models.PermLostReportsLink), controllers.LinkLostReport)
+			admin.DELETE("/lost-reports/:id/link", middleware.RequirePermission(models.PermLostReportsLink), controllers.UnlinkLostReport)
+			admin.GET("/trash", middleware.RequirePermission(models.PermTrashManage), controllers.GetTrash)
+			admin.POST("/trash/purge", middleware.RequirePermission(models.PermTrashManage), controllers.PurgeTrash)
+			admin.GET("/roles", middleware.RequirePermission(models.PermRolesAssign), controllers.GetRoles)
+			admin.PUT("/users/:id/role", middleware.RequirePermission(models.PermRolesAssign), controllers.AssignRole)
+			admin.GET("/users", middleware.RequirePermission(models.PermUsersManage), controllers.GetUsers)
+			admin.GET("/users/:id", middleware.RequirePermission(models.PermUsersManage), controllers.GetUser)
+			admin.POST("/users/:id/suspend", middleware.RequirePermission(models.PermUsersManage), controllers.SuspendUser)
+			admin.POST("/users/:id/unsuspend", middleware.RequirePermission(models.PermUsersManage), controllers.UnsuspendUser)
+			admin.POST("/users/:id/reset-password", middleware.RequirePermission(models.PermUsersManage), controllers.ResetUserPassword)
+			admin.DELETE("/users/:id", middleware.RequirePermission(models.PermUsersManage), controllers.DeleteUser)
+			admin.POST("/users/:id/logout-all", middleware.RequirePermission(models.PermUsersManage), controllers.LogoutUserEverywhere)
+			admin.POST("/users/:id/2fa/reset", middleware.RequirePermission(models.PermUsersManage), controllers.ResetUserTwoFactor)
+			admin.GET("/audit", middleware.RequirePermission(models.PermAuditRead), controllers.GetAuditLogs)
+			admin.GET("/audit/export", middleware.RequirePermission(models.PermAuditRead), controllers.ExportAuditLogs) // CSV
 		}
 	}
 
@@ -0,0 +1,237 @@
+// Package sessionstore adalah store gin-contrib/sessions yang menyimpan session di PostgreSQL,
+// sehingga session bisa didaftar per perangkat dan di-revoke dari server.
+// Cookie hanya membawa token acak yang ditandatangani; nilai session ada di tabel user_sessions.
+package sessionstore
+
+import (
+	"bytes"
+	"encoding/gob"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"filoti-backend/authtoken"
+	"filoti-backend/models"
+
+	"github.com/gin-contrib/sessions"
+	"github.com/gorilla/securecookie"
+	gsessions "github.com/gorilla/sessions"
+	"gorm.io/gorm"
+)
+
+// UserIDKey adalah key session yang berisi ID user yang login (diset oleh controllers.Login)
+const UserIDKey = "id"
+
+// Interval minimum antara update last_seen_at, agar tidak menulis ke database di setiap request
+const touchInterval = time.Minute
+
+// Store mengimplementasikan sessions.Store
+type Store struct {
+	db       *gorm.DB
+	codecs   []securecookie.Codec
+	options  *gsessions.Options
+	ipHeader string
+}
+
+// New membuat Store; keyPairs dipakai untuk menandatangani cookie seperti cookie.NewStore
+func New(db *gorm.DB, keyPairs ...[]byte) *Store {
+	return &Store{
+		db:      db,
+		codecs:  securecookie.CodecsFromPairs(keyPairs...),
+		options: &gsessions.Options{Path: "/", MaxAge: 86400 * 30},
+	}
+}
+
+// Options mengatur opsi cookie default
+func (s *Store) Options(options sessions.Options) {
+	s.options = options.ToGorillaOptions()
+}
+
+// TrustedPlatform mengatur header IP klien yang dipercaya, samakan dengan gin.Engine.TrustedPlatform
+// agar IP di daftar session sama dengan c.ClientIP(). Kosong berarti IP diambil dari koneksi.
+func (s *Store) TrustedPlatform(header string) {
+	s.ipHeader = header
+}
+
+// Get mengembalikan session yang di-cache untuk request ini
+func (s *Store) Get(r *http.Request, name string) (*gsessions.Session, error) {
+	return gsessions.GetRegistry(r).Get(s, name)
+}
+
+// New memuat session dari cookie. Cookie yang tidak valid, kedaluwarsa atau sudah di-revoke
+// menghasilkan session baru yang kosong (user dianggap belum login).
+func (s *Store) New(r *http.Request, name string) (*gsessions.Session, error) {
+	session := gsessions.NewSession(s, name)
+	opts := *s.options
+	session.Options = &opts
+	session.IsNew = true
+
+	cookie, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	var token string
+	if err := securecookie.DecodeMulti(name, cookie.Value, &token, s.codecs...); err != nil {
+		return session, nil // Termasuk cookie lama dari cookie store
+	}
+
+	row, err := s.active(token)
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return session, nil
+		}
+		return session, err
+	}
+	if err := gob.NewDecoder(bytes.NewReader(row.Data)).Decode(&session.Values); err != nil {
+		return session, nil
+	}
+	session.ID = token
+	session.IsNew = false
+
+	if time.Since(row.LastSeenAt) > touchInterval {
+		s.db.Model(&models.UserSession{}).Where("id = ?", row.ID).Updates(map[string]interface{}{
+			"last_seen_at": time.Now(),
+			"ip":           s.clientIP(r),
+			"user_agent":   r.UserAgent(),
+		})
+	}
+	return session, nil
+}
+
+// Save menyimpan session. MaxAge < 0 (Logout) me-revoke session di server.
+// Token diganti setiap kali user yang login berubah, untuk mencegah session fixation.
+func (s *Store) Save(r *http.Request, w http.ResponseWriter, session *gsessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			if err := s.revoke(session.ID); err != nil {
+				return err
+			}
+		}
+		http.SetCookie(w, gsessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	var data bytes.Buffer
+	if err := gob.NewEncoder(&data).Encode(session.Values); err != nil {
+		return err
+	}
+	userID := userIDOf(session.Values)
+	now := time.Now()
+	expires := now.Add(time.Duration(session.Options.MaxAge) * time.Second)
+	if session.Options.MaxAge == 0 {
+		expires = now.Add(24 * time.Hour) // Cookie sesi browser; batasi tetap di server
+	}
+
+	var existing *models.UserSession
+	if session.ID != "" {
+		if row, err := s.active(session.ID); err == nil {
+			existing = &row
+		} else if err != gorm.ErrRecordNotFound {
+			return err
+		}
+	}
+
+	if existing != nil && sameUser(existing.UserID, userID) {
+		if err := s.db.Model(existing).Updates(map[string]interface{}{
+			"data":         data.Bytes(),
+			"expires_at":   expires,
+			"last_seen_at": now,
+		}).Error; err != nil {
+			return err
+		}
+	} else {
+		if existing != nil {
+			if err := s.revoke(session.ID); err != nil {
+				return err
+			}
+		}
+		token, err := authtoken.RandomToken(32)
+		if err != nil {
+			return err
+		}
+		row := models.UserSession{
+			TokenHash:  authtoken.HashToken(token),
+			UserID:     userID,
+			Data:       data.Bytes(),
+			IP:         s.clientIP(r),
+			UserAgent:  r.UserAgent(),
+			LastSeenAt: now,
+			ExpiresAt:  expires,
+		}
+		if err := s.db.Create(&row).Error; err != nil {
+			return err
+		}
+		session.ID = token
+		if userID != nil {
+			// Bersihkan session kedaluwarsa milik user yang sama
+			s.db.Where("user_id = ? AND expires_at < ?", *userID, now).Delete(&models.UserSession{})
+		}
+	}
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, gsessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// TokenHash mengembalikan hash yang disimpan untuk ID session (sessions.Session.ID())
+func TokenHash(sessionID string) string {
+	return authtoken.HashToken(sessionID)
+}
+
+// Purge menghapus semua session yang sudah kedaluwarsa atau di-revoke, termasuk session tanpa
+// login (misalnya dari /auth/oidc/login) yang tidak pernah dibersihkan saat login. Dipanggil oleh purger berkala.
+func Purge(db *gorm.DB) (int64, error) {
+	result := db.Where("expires_at < ? OR revoked_at IS NOT NULL", time.Now()).Delete(&models.UserSession{})
+	return result.RowsAffected, result.Error
+}
+
+func (s *Store) active(token string) (models.UserSession, error) {
+	var row models.UserSession
+	err := s.db.Where("token_hash = ? AND revoked_at IS NULL AND expires_at > ?", authtoken.HashToken(token), time.Now()).
+		First(&row).Error
+	return row, err
+}
+
+func (s *Store) revoke(token string) error {
+	return s.db.Model(&models.UserSession{}).
+		Where("token_hash = ? AND revoked_at IS NULL", authtoken.HashToken(token)).
+		Update("revoked_at", time.Now()).Error
+}
+
+func userIDOf(values map[interface{}]interface{}) *uint {
+	var id uint
+	switch v := values[UserIDKey].(type) {
+	case int:
+		id = uint(v)
+	case uint:
+		id = v
+	default:
+		return nil
+	}
+	return &id
+}
+
+func sameUser(a, b *uint) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+// clientIP mengambil IP klien dengan aturan yang sama seperti c.ClientIP() tanpa trusted proxy
+func (s *Store) clientIP(r *http.Request) string {
+	if s.ipHeader != "" {
+		if ip := strings.TrimSpace(r.Header.Get(s.ipHeader)); ip != "" {
+			return ip
+		}
+	}
+	host, _, err := net.SplitHostPort(strings.TrimSpace(r.RemoteAddr))
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
@@ -0,0 +1,58 @@
+// Package storage menyimpan file upload (gambar post, bukti klaim) di balik
+// satu interface, dengan implementasi filesystem lokal dan S3-compatible (AWS S3, MinIO, R2).
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Storage adalah backend penyimpanan objek. Key memakai "/" sebagai pemisah path.
+type Storage interface {
+	Put(ctx context.Context, key string, data []byte, contentType string) error
+	Delete(ctx context.Context, key string) error
+	// URL mengembalikan URL publik untuk key
+	URL(key string) string
+}
+
+// FromEnv membuat Storage berdasarkan STORAGE_DRIVER ("local" atau "s3").
+// Di Vercel filesystem-nya read-only, jadi hanya driver s3 yang diterima.
+func FromEnv() (Storage, error) {
+	driver := strings.ToLower(os.Getenv("STORAGE_DRIVER"))
+	if os.Getenv("VERCEL") != "" && driver != "s3" {
+		return nil, errors.New("STORAGE_DRIVER=s3 is required on Vercel (local filesystem is read-only)")
+	}
+	switch driver {
+	case "", "local":
+		dir := os.Getenv("STORAGE_LOCAL_DIR")
+		if dir == "" {
+			dir = "uploads"
+		}
+		baseURL := os.Getenv("STORAGE_PUBLIC_URL")
+		if baseURL == "" {
+			baseURL = "http://localhost:8080/uploads"
+		}
+		return NewLocal(dir, baseURL), nil
+	case "s3":
+		cfg := S3Config{
+			Endpoint:  os.Getenv("S3_ENDPOINT"),
+			Region:    os.Getenv("S3_REGION"),
+			Bucket:    os.Getenv("S3_BUCKET"),
+			AccessKey: os.Getenv("S3_ACCESS_KEY"),
+			SecretKey: os.Getenv("S3_SECRET_KEY"),
+			PublicURL: os.Getenv("S3_PUBLIC_URL"),
+			PathStyle: os.Getenv("S3_PATH_STYLE") == "true",
+		}
+		if cfg.Endpoint == "" || cfg.Bucket == "" || cfg.AccessKey == "" || cfg.SecretKey == "" {
+			return nil, errors.New("S3_ENDPOINT, S3_BUCKET, S3_ACCESS_KEY and S3_SECRET_KEY must be set for the s3 storage driver")
+		}
+		if cfg.Region == "" {
+			cfg.Region = "us-east-1"
+		}
+		return NewS3(cfg), nil
+	}
+	return nil, fmt.Errorf("unknown STORAGE_DRIVER %q", driver)
+}
@@ -0,0 +1,22 @@
+package storage
+
+import "testing"
+
+func TestFromEnvRequiresS3OnVercel(t *testing.T) {
+	t.Setenv("VERCEL", "1")
+	for _, driver := range []string{"", "local"} {
+		t.Setenv("STORAGE_DRIVER", driver)
+		if _, err := FromEnv(); err == nil {
+			t.Errorf("FromEnv accepted STORAGE_DRIVER=%q on Vercel", driver)
+		}
+	}
+
+	t.Setenv("STORAGE_DRIVER", "s3")
+	t.Setenv("S3_ENDPOINT", "https://s3.example.com")
+	t.Setenv("S3_BUCKET", "filoti")
+	t.Setenv("S3_ACCESS_KEY", "key")
+	t.Setenv("S3_SECRET_KEY", "secret")
+	if _, err := FromEnv(); err != nil {
+		t.Errorf("FromEnv with s3 on Vercel: %v", err)
+	}
+}
@@ -0,0 +1,129 @@
+package totp
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// Secret ASCII "12345678901234567890" dari lampiran B RFC 6238 (HMAC-SHA1), dalam base32
+const rfcSecret = "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ"
+
+// Vektor uji RFC 6238 lampiran B; RFC memakai 8 digit, kode 6 digit adalah 6 digit terakhirnya
+var rfcVectors = []struct {
+	unix int64
+	step int64
+	code string
+}{
+	{59, 0x1, "287082"},
+	{1111111109, 0x23523EC, "081804"},
+	{1111111111, 0x23523ED, "050471"},
+	{1234567890, 0x273EF07, "005924"},
+	{2000000000, 0x3F940AA, "279037"},
+	{20000000000, 0x27BC86AA, "353130"},
+}
+
+func TestCodeMatchesRFC6238(t *testing.T) {
+	for _, v := range rfcVectors {
+		tm := time.Unix(v.unix, 0)
+		if got := Step(tm); got != v.step {
+			t.Errorf("Step(%d) = %#x, want %#x", v.unix, got, v.step)
+		}
+		got, err := Code(rfcSecret, tm)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != v.code {
+			t.Errorf("Code(%d) = %s, want %s", v.unix, got, v.code)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	now := time.Unix(1111111111, 0) // step 0x23523ED, kode 050471
+	current := Step(now)
+
+	tests := []struct {
+		name     string
+		secret   string
+		code     string
+		skew     int
+		wantStep int64
+		wantOK   bool
+	}{
+		{"current step", rfcSecret, "050471", 1, current, true},
+		{"lowercase secret and spaces", strings.ToLower(rfcSecret), " 050 471 ", 1, current, true},
+		{"previous step within skew", rfcSecret, "081804", 1, current - 1, true},
+		{"previous step without skew", rfcSecret, "081804", 0, 0, false},
+		{"two steps back outside skew", rfcSecret, mustCode(t, now.Add(-2*Period*time.Second)), 1, 0, false},
+		{"next step within skew", rfcSecret, mustCode(t, now.Add(Period*time.Second)), 1, current + 1, true},
+		{"wrong code", rfcSecret, "000000", 1, 0, false},
+		{"too short", rfcSecret, "05047", 1, 0, false},
+		{"too long", rfcSecret, "0504710", 1, 0, false},
+		{"invalid secret", "not base32!", "050471", 1, 0, false},
+		{"empty secret", "", "050471", 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(tt.secret, tt.code, now, tt.skew)
+			if ok != tt.wantOK || step != tt.wantStep {
+				t.Errorf("Validate = (%#x, %v), want (%#x, %v)", step, ok, tt.wantStep, tt.wantOK)
+			}
+		})
+	}
+}
+
+// Pemanggil menolak langkah <= langkah terakhir yang dipakai; Validate harus mengembalikan
+// langkah yang sama untuk kode yang sama agar kode dalam jendela skew tidak bisa dipakai ulang.
+func TestValidateReplay(t *testing.T) {
+	now := time.Unix(1111111111, 0)
+	code := mustCode(t, now)
+
+	lastUsed, ok := Validate(rfcSecret, code, now, 1)
+	if !ok {
+		t.Fatal("first use rejected")
+	}
+	for _, later := range []time.Time{now, now.Add(Period * time.Second)} {
+		step, ok := Validate(rfcSecret, code, later, 1)
+		if !ok {
+			t.Fatalf("code not valid at %v", later)
+		}
+		if step > lastUsed {
+			t.Errorf("replayed code at %v returned step %#x > last used %#x", later, step, lastUsed)
+		}
+	}
+
+	// Kode langkah berikutnya tetap diterima setelah kode sebelumnya dipakai
+	next := mustCode(t, now.Add(Period*time.Second))
+	if step, ok := Validate(rfcSecret, next, now, 1); !ok || step <= lastUsed {
+		t.Errorf("next code = (%#x, %v), want step > %#x", step, ok, lastUsed)
+	}
+}
+
+func TestGenerateSecretRoundTrip(t *testing.T) {
+	secret, err := GenerateSecret()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(secret) != 32 {
+		t.Errorf("secret length = %d, want 32", len(secret))
+	}
+	now := time.Now()
+	if _, ok := Validate(secret, mustCodeFor(t, secret, now), now, 0); !ok {
+		t.Error("generated secret does not validate its own code")
+	}
+}
+
+func mustCode(t *testing.T, tm time.Time) string {
+	t.Helper()
+	return mustCodeFor(t, rfcSecret, tm)
+}
+
+func mustCodeFor(t *testing.T, secret string, tm time.Time) string {
+	t.Helper()
+	code, err := Code(secret, tm)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return code
+}